@@ -0,0 +1,32 @@
+package blinktest
+
+import (
+	"sync"
+	"time"
+)
+
+// A Clock is a clock that only moves when it is advanced explicitly.
+// Use it with Emulator.SetClock to test fades and patterns without waiting.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock creates a new Clock which starts at a fixed point in time.
+func NewClock() *Clock {
+	return &Clock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,437 @@
+// Package blinktest provides utilities to test code that uses the blink package without a device.
+package blinktest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fgrosse/blink"
+)
+
+// tick is the time the emulated firmware needs to process a pattern line
+// which has no fade duration if all lines of the played range have none.
+const tick = 10 * time.Millisecond
+
+// An Emulator emulates the firmware of a blink(1) device. It implements
+// blink.Transport and processes the same feature reports as a real device,
+// so it can be used via blink.NewLED to test the whole library end-to-end.
+//
+// The emulator models the color of each LED including fades in progress,
+// the color pattern table, the pattern playback and the serverdown watchdog.
+// All of them are evaluated against the clock of the emulator which can be
+// replaced via SetClock to test time dependent behavior deterministically.
+//
+// An Emulator is safe for concurrent use.
+type Emulator struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	firmware int
+	closed   bool
+	response []byte // the report which is returned by the next read
+
+	leds    []fade // the state of each LED, led#1 is at index 0
+	ledn    byte   // the LED that the next pattern line addresses
+	pattern []blink.PatternLine
+	flash   []blink.PatternLine // the pattern that is restored on a power cycle
+
+	play struct {
+		playing         bool
+		start, end, pos int
+		count           int
+		next            time.Time // when the line at pos is played
+	}
+
+	serverdown struct {
+		armed      bool
+		deadline   time.Time
+		start, end int
+	}
+
+	eeprom  [blink.EEPROMSize]byte
+	startup blink.StartupParams
+	notes   [blink.NoteCount][blink.NoteSize]byte
+	chipID  [8]byte
+}
+
+// fade describes the color of an LED which changes from one color to another over time.
+type fade struct {
+	from, to blink.Color
+	start    time.Time
+	duration time.Duration
+}
+
+// NewEmulator creates an Emulator of a device with the given firmware version,
+// e.g. 105 for a mk1, 204 for a mk2 or 302 for a mk3 device.
+func NewEmulator(firmware int) *Emulator {
+	e := &Emulator{
+		now:      time.Now,
+		firmware: firmware,
+		chipID:   [8]byte{0xb1, 0x1c, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+	}
+
+	leds, lines := 2, 32
+	if firmware < 200 {
+		leds, lines = 1, 12
+	}
+
+	e.leds = make([]fade, leds)
+	e.pattern = make([]blink.PatternLine, lines)
+	e.flash = make([]blink.PatternLine, lines)
+
+	return e
+}
+
+// SetClock replaces the clock of the emulator which defaults to time.Now.
+// It must be called before the emulator is used and now must be safe for concurrent use.
+// Example:
+//     clock := blinktest.NewClock()
+//     e := blinktest.NewEmulator(204)
+//     e.SetClock(clock.Now)
+func (e *Emulator) SetClock(now func() time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.now = now
+}
+
+// WriteReport implements blink.Transport by processing the given report like the firmware does.
+func (e *Emulator) WriteReport(ctx context.Context, report []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return fmt.Errorf("emulator has been closed: %w", blink.ErrDisconnected)
+	}
+
+	if len(report) < 8 {
+		return fmt.Errorf("report is too short: % x", report)
+	}
+
+	now := e.now()
+	e.advance(now)
+
+	// reports without a specific response are returned as they are
+	e.response = append([]byte(nil), report...)
+	e.handle(now, report)
+	return nil
+}
+
+// ReadReport implements blink.Transport by returning the response to the last written report.
+func (e *Emulator) ReadReport(ctx context.Context, report []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return fmt.Errorf("emulator has been closed: %w", blink.ErrDisconnected)
+	}
+
+	if e.response == nil || e.response[0] != report[0] {
+		return fmt.Errorf("no response for report %d: %w", report[0], blink.ErrPipe)
+	}
+
+	copy(report, e.response)
+	return nil
+}
+
+// Close implements blink.Transport. All transfers after Close fail with blink.ErrDisconnected.
+func (e *Emulator) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.closed = true
+	return nil
+}
+
+// Closed returns true if the emulator has been closed.
+func (e *Emulator) Closed() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.closed
+}
+
+// PowerCycle emulates unplugging and reconnecting the device. All LEDs are
+// turned off, the pattern stops and the pattern table is restored from flash.
+func (e *Emulator) PowerCycle() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i := range e.leds {
+		e.leds[i] = fade{}
+	}
+
+	copy(e.pattern, e.flash)
+	e.play.playing = false
+	e.serverdown.armed = false
+	e.closed = false
+}
+
+// Color returns the current color of the LED with the given index: 1=led#1, 2=led#2, etc.
+// Index 0 returns the color of the first LED.
+func (e *Emulator) Color(n int) blink.Color {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	e.advance(now)
+	return e.leds[e.index(byte(n))].color(now)
+}
+
+// PatternLine returns the line at the given position of the pattern table.
+func (e *Emulator) PatternLine(pos int) blink.PatternLine {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.pattern[pos]
+}
+
+// PlayState returns the current state of the pattern playback.
+func (e *Emulator) PlayState() blink.PlayState {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.advance(e.now())
+	return e.playState()
+}
+
+// ServerdownArmed returns true if the serverdown watchdog is armed and has not yet been triggered.
+func (e *Emulator) ServerdownArmed() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.advance(e.now())
+	return e.serverdown.armed
+}
+
+// handle processes a single report. The response is written into e.response.
+func (e *Emulator) handle(now time.Time, r []byte) {
+	if r[0] == 2 {
+		e.handleReport2(r)
+		return
+	}
+
+	switch r[1] {
+	case 'n': // set color now
+		e.play.playing = false
+		e.fadeTo(now, 0, blink.Color{R: r[2], G: r[3], B: r[4]}, 0)
+	case 'c': // fade to color
+		e.play.playing = false
+		e.fadeTo(now, r[7], blink.Color{R: r[2], G: r[3], B: r[4]}, duration(r[5], r[6]))
+	case 'r': // read color
+		if e.firmware < 200 {
+			return // not supported by mk1
+		}
+		c := e.leds[e.index(r[7])].color(now)
+		e.respond(r, c.R, c.G, c.B, 0, 0, r[7])
+	case 'v': // read firmware version
+		e.respond(r, 0, byte('0'+e.firmware/100), byte('0'+e.firmware%100), 0, 0, 0)
+	case '!': // test
+		e.respond(r, 0x55, 0xAA, 0, 0, 0, 0)
+	case 'l': // set LED of the next pattern line
+		e.ledn = r[2]
+	case 'P': // set pattern line
+		if pos := int(r[7]); pos < len(e.pattern) {
+			e.pattern[pos] = blink.PatternLine{
+				Color:        blink.Color{R: r[2], G: r[3], B: r[4]},
+				FadeDuration: duration(r[5], r[6]),
+				LEDIndex:     e.ledn,
+			}
+		}
+	case 'R': // read pattern line
+		if pos := int(r[7]); pos < len(e.pattern) {
+			l := e.pattern[pos]
+			t := l.FadeDuration / tick
+			e.respond(r, l.Color.R, l.Color.G, l.Color.B, byte(t>>8), byte(t&0xff), l.LEDIndex)
+		}
+	case 'W': // save pattern to flash
+		if e.firmware >= 200 && r[2] == 0xBE && r[3] == 0xEF && r[4] == 0xCA && r[5] == 0xFE {
+			copy(e.flash, e.pattern)
+		}
+	case 'p': // play or stop pattern
+		if r[2] == 0 {
+			e.play.playing = false
+			return
+		}
+
+		start, end, count := int(r[3]), int(r[4]), int(r[5])
+		if e.firmware < 200 || end == 0 {
+			end = len(e.pattern) - 1 // mk1 devices always play the whole pattern
+		}
+		if e.firmware < 200 {
+			count = 0
+		}
+		e.startPlaying(now, start, end, count)
+	case 'S': // read play state
+		s := e.playState()
+		e.respond(r, boolByte(s.Playing), byte(s.Start), byte(s.End), byte(s.Count), byte(s.Position), 0)
+	case 'D': // serverdown
+		e.serverdown.armed = r[2] != 0
+		if e.serverdown.armed {
+			e.serverdown.deadline = now.Add(duration(r[3], r[4]))
+			e.serverdown.start, e.serverdown.end = int(r[6]), int(r[7])
+			if e.firmware < 200 || e.serverdown.end == 0 {
+				e.serverdown.start, e.serverdown.end = 0, len(e.pattern)-1
+			}
+		} else if r[5] == 0 {
+			e.play.playing = false
+			e.fadeTo(now, 0, blink.Color{}, 0)
+		}
+	case 'e': // read EEPROM
+		if e.firmware < 200 {
+			e.respond(r, r[2], e.eeprom[r[2]], 0, 0, 0, 0)
+		}
+	case 'E': // write EEPROM
+		if e.firmware < 200 {
+			e.eeprom[r[2]] = r[3]
+		}
+	case 'B': // set startup parameters
+		if e.firmware >= 300 {
+			e.startup = blink.StartupParams{Mode: blink.BootMode(r[2]), Start: int(r[3]), End: int(r[4]), Count: int(r[5])}
+		}
+	case 'b': // read startup parameters
+		if e.firmware >= 300 {
+			p := e.startup
+			e.respond(r, byte(p.Mode), byte(p.Start), byte(p.End), byte(p.Count), 0, 0)
+		}
+	}
+}
+
+// handleReport2 processes the larger reports of mk3 devices.
+func (e *Emulator) handleReport2(r []byte) {
+	if e.firmware < 300 || len(r) < 3+blink.NoteSize {
+		return
+	}
+
+	switch r[1] {
+	case 'F': // write note
+		if id := int(r[2]); id < blink.NoteCount {
+			copy(e.notes[id][:], r[3:])
+		}
+	case 'f': // read note
+		if id := int(r[2]); id < blink.NoteCount {
+			copy(e.response[3:], e.notes[id][:])
+		}
+	case 'U': // read chip ID
+		copy(e.response[3:], e.chipID[:])
+	}
+}
+
+// respond sets the response to the report r with the given payload.
+func (e *Emulator) respond(r []byte, payload ...byte) {
+	e.response = append([]byte{r[0], r[1]}, payload...)
+}
+
+// index returns the index into e.leds of the LED with the given number.
+func (e *Emulator) index(n byte) int {
+	if n == 0 || int(n) > len(e.leds) {
+		return 0
+	}
+
+	return int(n) - 1
+}
+
+// fadeTo starts fading the LED with the given number to c at the given time.
+func (e *Emulator) fadeTo(at time.Time, n byte, c blink.Color, d time.Duration) {
+	for i := range e.leds {
+		if n == 0 || int(n) == i+1 {
+			e.leds[i] = fade{from: e.leds[i].color(at), to: c, start: at, duration: d}
+		}
+	}
+}
+
+func (e *Emulator) startPlaying(at time.Time, start, end, count int) {
+	e.play.playing = true
+	e.play.start, e.play.end, e.play.pos = start, end, start
+	e.play.count = count
+	e.play.next = at
+}
+
+func (e *Emulator) playState() blink.PlayState {
+	return blink.PlayState{
+		Playing:  e.play.playing,
+		Start:    e.play.start,
+		End:      e.play.end,
+		Count:    e.play.count,
+		Position: e.play.pos,
+	}
+}
+
+// advance updates the state of the emulator to the given time by triggering
+// the serverdown watchdog and playing the pattern lines that are due.
+func (e *Emulator) advance(now time.Time) {
+	if e.serverdown.armed && !now.Before(e.serverdown.deadline) {
+		e.serverdown.armed = false
+		e.startPlaying(e.serverdown.deadline, e.serverdown.start, e.serverdown.end, 0)
+	}
+
+	var zeroLines int // the number of consecutive lines without duration
+	for e.play.playing && !now.Before(e.play.next) {
+		if e.play.pos < 0 || e.play.pos >= len(e.pattern) || e.play.end < e.play.start {
+			e.play.playing = false
+			return
+		}
+
+		line := e.pattern[e.play.pos]
+		e.fadeTo(e.play.next, line.LEDIndex, line.Color, line.FadeDuration)
+
+		d := line.FadeDuration
+		if d == 0 {
+			zeroLines++
+			if zeroLines > e.play.end-e.play.start {
+				d = tick // prevents an endless loop if no line has a duration
+			}
+		} else {
+			zeroLines = 0
+		}
+
+		e.play.next = e.play.next.Add(d)
+		e.play.pos++
+		if e.play.pos > e.play.end {
+			e.play.pos = e.play.start
+			if e.play.count > 0 {
+				e.play.count--
+				if e.play.count == 0 {
+					e.play.playing = false
+				}
+			}
+		}
+	}
+}
+
+// color returns the color of the LED at the given time.
+func (f fade) color(at time.Time) blink.Color {
+	elapsed := at.Sub(f.start)
+	if f.duration <= 0 || elapsed >= f.duration {
+		return f.to
+	}
+
+	if elapsed <= 0 {
+		return f.from
+	}
+
+	p := float64(elapsed) / float64(f.duration)
+	mix := func(from, to byte) byte {
+		return byte(float64(from) + (float64(to)-float64(from))*p)
+	}
+
+	return blink.Color{R: mix(f.from.R, f.to.R), G: mix(f.from.G, f.to.G), B: mix(f.from.B, f.to.B)}
+}
+
+// duration decodes a duration which is sent in steps of 10ms.
+func duration(high, low byte) time.Duration {
+	return time.Duration(int(high)<<8|int(low)) * tick
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,188 @@
+package blinktest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fgrosse/blink"
+	"github.com/fgrosse/blink/blinktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEmulatedLED(t *testing.T, firmware int) (*blink.LED, *blinktest.Emulator, *blinktest.Clock) {
+	clock := blinktest.NewClock()
+	e := blinktest.NewEmulator(firmware)
+	e.SetClock(clock.Now)
+
+	led := blink.NewLED(e)
+	t.Cleanup(func() { led.Close() })
+
+	return led, e, clock
+}
+
+func TestEmulatorSetAndRead(t *testing.T) {
+	led, e, _ := newEmulatedLED(t, 204)
+
+	require.NoError(t, led.Set(blink.Red))
+	assert.Equal(t, blink.Red, e.Color(1))
+	assert.Equal(t, blink.Red, e.Color(2))
+
+	c, err := led.Read()
+	require.NoError(t, err)
+	assert.Equal(t, blink.Red, c)
+
+	v, err := led.Version()
+	require.NoError(t, err)
+	assert.Equal(t, 204, v)
+
+	assert.NoError(t, led.SelfTest())
+}
+
+func TestEmulatorFadesOverTime(t *testing.T) {
+	led, e, clock := newEmulatedLED(t, 204)
+
+	require.NoError(t, led.Fade(blink.Color{R: 200}, time.Second))
+	assert.Equal(t, blink.Color{}, e.Color(1))
+
+	clock.Advance(500 * time.Millisecond)
+	assert.Equal(t, blink.Color{R: 100}, e.Color(1))
+
+	c, err := led.Read()
+	require.NoError(t, err)
+	assert.Equal(t, blink.Color{R: 100}, c)
+
+	clock.Advance(time.Second)
+	assert.Equal(t, blink.Color{R: 200}, e.Color(1))
+}
+
+func TestEmulatorAddressesLEDs(t *testing.T) {
+	led, e, _ := newEmulatedLED(t, 204)
+
+	require.NoError(t, led.SetEach(blink.Red, blink.Blue))
+	assert.Equal(t, blink.Red, e.Color(1))
+	assert.Equal(t, blink.Blue, e.Color(2))
+
+	c, err := led.Channel(2).Read()
+	require.NoError(t, err)
+	assert.Equal(t, blink.Blue, c)
+}
+
+func TestEmulatorPlaysPattern(t *testing.T) {
+	led, e, clock := newEmulatedLED(t, 204)
+
+	p, err := blink.NewSequence().
+		Fade(blink.Red, 100*time.Millisecond).
+		Fade(blink.Blue, 100*time.Millisecond).
+		LoopN(2).
+		Compile()
+	require.NoError(t, err)
+
+	require.NoError(t, led.WritePattern(p))
+	assert.Equal(t, blink.PatternLine{Color: blink.Blue, FadeDuration: 100 * time.Millisecond}, e.PatternLine(3))
+
+	read, err := led.ReadPatternLine(1)
+	require.NoError(t, err)
+	assert.Equal(t, p.Lines[1], read)
+
+	require.NoError(t, led.PlayPatternLoop(p.Start, p.End, p.Count))
+	clock.Advance(150 * time.Millisecond)
+
+	state, err := led.PlayState()
+	require.NoError(t, err)
+	assert.Equal(t, blink.PlayState{Playing: true, Start: 0, End: 3, Count: 1, Position: 2}, state)
+	assert.Equal(t, blink.Color{R: 127, B: 127}, e.Color(1))
+
+	clock.Advance(time.Second)
+	assert.False(t, e.PlayState().Playing)
+	assert.Equal(t, blink.Blue, e.Color(1))
+}
+
+func TestEmulatorSavesPatternToFlash(t *testing.T) {
+	led, e, _ := newEmulatedLED(t, 204)
+
+	require.NoError(t, led.SetPatternLine(0, blink.PatternLine{Color: blink.Red}))
+	require.NoError(t, led.SavePatterns())
+	require.NoError(t, led.SetPatternLine(0, blink.PatternLine{Color: blink.Blue}))
+
+	e.PowerCycle()
+	assert.Equal(t, blink.Red, e.PatternLine(0).Color)
+}
+
+func TestEmulatorServerdown(t *testing.T) {
+	led, e, clock := newEmulatedLED(t, 204)
+
+	require.NoError(t, led.SetPatternLine(0, blink.PatternLine{Color: blink.Red, FadeDuration: time.Second}))
+	require.NoError(t, led.SetPatternLine(1, blink.PatternLine{Color: blink.Blue, FadeDuration: time.Second}))
+	require.NoError(t, led.Tickle(blink.ServerdownConfig{Timeout: 5 * time.Second, Start: 0, End: 1}))
+
+	clock.Advance(4 * time.Second)
+	require.NoError(t, led.Tickle(blink.ServerdownConfig{Timeout: 5 * time.Second, Start: 0, End: 1}))
+	clock.Advance(4 * time.Second)
+	assert.True(t, e.ServerdownArmed())
+	assert.Equal(t, blink.Color{}, e.Color(1))
+
+	clock.Advance(2 * time.Second)
+	assert.False(t, e.ServerdownArmed())
+	assert.True(t, e.PlayState().Playing)
+	assert.Equal(t, blink.Red, e.Color(1))
+}
+
+func TestEmulatorMK1(t *testing.T) {
+	led, _, _ := newEmulatedLED(t, 105)
+
+	caps, err := led.Capabilities()
+	require.NoError(t, err)
+	assert.Equal(t, blink.MK1, caps.Generation)
+
+	led.UnlockEEPROM()
+	require.NoError(t, led.WriteEEPROM(3, 0x42))
+	val, err := led.ReadEEPROM(3)
+	require.NoError(t, err)
+	assert.Equal(t, byte(0x42), val)
+}
+
+func TestEmulatorMK3(t *testing.T) {
+	led, _, _ := newEmulatedLED(t, 302)
+
+	require.NoError(t, led.WriteNote(1, []byte("hello")))
+	note, err := led.ReadNote(1)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), note)
+
+	params := blink.StartupParams{Mode: blink.BootPlay, Start: 1, End: 2, Count: 3}
+	require.NoError(t, led.SetStartupParams(params))
+	read, err := led.StartupParams()
+	require.NoError(t, err)
+	assert.Equal(t, params, read)
+
+	id, err := led.ChipID()
+	require.NoError(t, err)
+	assert.Len(t, id, 16)
+}
+
+func TestEmulatorPlaysSequence(t *testing.T) {
+	led, e, _ := newEmulatedLED(t, 204)
+
+	s := blink.NewSequence().
+		Set(blink.Red, time.Millisecond).
+		SetLED(2, blink.Green, time.Millisecond).
+		LoopN(2).
+		Fade(blink.Blue, 0)
+
+	require.NoError(t, s.Play(led))
+	assert.Equal(t, blink.Blue, e.Color(1))
+	assert.Equal(t, blink.Blue, e.Color(2))
+}
+
+func TestEmulatorIsDisconnectedAfterClose(t *testing.T) {
+	led, e, _ := newEmulatedLED(t, 204)
+
+	require.NoError(t, led.Close())
+	assert.True(t, e.Closed())
+
+	err := e.WriteReport(context.Background(), make([]byte, 8))
+	assert.True(t, errors.Is(err, blink.ErrDisconnected))
+}
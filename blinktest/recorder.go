@@ -0,0 +1,249 @@
+package blinktest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fgrosse/blink"
+)
+
+// A Command is a decoded report that was sent to a Recorder.
+type Command struct {
+	Time     time.Time     // when the command was received
+	Op       byte          // the command byte of the report, e.g. 'n' for set or 'c' for fade
+	Color    blink.Color   // the color of set and fade commands
+	Duration time.Duration // the duration of fade commands
+	LED      byte          // the LED that is addressed by fade and read commands: 0=all, 1=led#1, 2=led#2, etc.
+	Report   []byte        // the raw report
+}
+
+// Set returns the Command that is sent by blink.LED.Set.
+func Set(c blink.Color) Command {
+	return Command{Op: 'n', Color: c}
+}
+
+// Fade returns the Command that is sent by blink.LED.Fade.
+func Fade(c blink.Color, d time.Duration) Command {
+	return Command{Op: 'c', Color: c, Duration: d}
+}
+
+// FadeLED returns the Command that is sent by blink.LED.Fade if the LED has the given ID.
+func FadeLED(n byte, c blink.Color, d time.Duration) Command {
+	return Command{Op: 'c', Color: c, Duration: d, LED: n}
+}
+
+// IsColor returns true if the command changes the color of the device.
+func (c Command) IsColor() bool {
+	return c.Op == 'n' || c.Op == 'c'
+}
+
+// String implements fmt.Stringer.
+func (c Command) String() string {
+	switch c.Op {
+	case 'n':
+		return fmt.Sprintf("set %v", c.Color)
+	case 'c':
+		if c.LED != 0 {
+			return fmt.Sprintf("fade led#%d to %v in %s", c.LED, c.Color, c.Duration)
+		}
+		return fmt.Sprintf("fade to %v in %s", c.Color, c.Duration)
+	default:
+		return fmt.Sprintf("%q % x", c.Op, c.Report)
+	}
+}
+
+// equal returns true if both commands have the same effect on the color of the device.
+// Setting a color is the same as fading to it without a duration.
+func (c Command) equal(other Command) bool {
+	return c.IsColor() && other.IsColor() &&
+		c.Color == other.Color && c.Duration == other.Duration && c.LED == other.LED
+}
+
+// A Recorder is a fake device which records every command it receives.
+// It answers all reads via an embedded Emulator, so it can be used via
+// blink.NewLED like a real device, e.g. to play a Sequence.
+//
+// The assertion helpers of the Recorder can be used to check which colors
+// have been sent to the device and when.
+// Example:
+//     rec := blinktest.NewRecorder()
+//     led := blink.NewLED(rec)
+//
+//     myCode(led)
+//
+//     rec.AssertColors(t, blinktest.Set(blink.Red), blinktest.Fade(blink.Green, 500*time.Millisecond))
+//     rec.AssertFinalColor(t, blink.Green)
+type Recorder struct {
+	*Emulator
+
+	mu       sync.Mutex
+	commands []Command
+}
+
+// NewRecorder creates a Recorder which emulates a mk2 device with firmware v2.04.
+func NewRecorder() *Recorder {
+	return NewRecorderFor(NewEmulator(204))
+}
+
+// NewRecorderFor creates a Recorder that answers reads via the given Emulator.
+func NewRecorderFor(e *Emulator) *Recorder {
+	return &Recorder{Emulator: e}
+}
+
+// WriteReport implements blink.Transport by recording the report and passing it to the Emulator.
+func (r *Recorder) WriteReport(ctx context.Context, report []byte) error {
+	if err := r.Emulator.WriteReport(ctx, report); err != nil {
+		return err
+	}
+
+	r.Emulator.mu.Lock()
+	now := r.Emulator.now()
+	r.Emulator.mu.Unlock()
+
+	r.mu.Lock()
+	r.commands = append(r.commands, decode(now, report))
+	r.mu.Unlock()
+
+	return nil
+}
+
+func decode(now time.Time, report []byte) Command {
+	c := Command{Time: now, Op: report[1], Report: append([]byte(nil), report...)}
+	switch c.Op {
+	case 'n':
+		c.Color = blink.Color{R: report[2], G: report[3], B: report[4]}
+	case 'c':
+		c.Color = blink.Color{R: report[2], G: report[3], B: report[4]}
+		c.Duration = duration(report[5], report[6])
+		c.LED = report[7]
+	case 'r':
+		c.LED = report[7]
+	}
+
+	return c
+}
+
+// Commands returns all recorded commands.
+func (r *Recorder) Commands() []Command {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Command(nil), r.commands...)
+}
+
+// Colors returns all recorded commands that change the color of the device.
+// Queries like reading the color or the firmware version are omitted.
+func (r *Recorder) Colors() []Command {
+	var result []Command
+	for _, c := range r.Commands() {
+		if c.IsColor() {
+			result = append(result, c)
+		}
+	}
+
+	return result
+}
+
+// Reset removes all recorded commands.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.commands = nil
+	r.mu.Unlock()
+}
+
+// FinalColor returns the color the LED with the given index has after all
+// recorded commands have been executed and all fades are complete.
+// Index 0 returns the color of the first LED.
+func (r *Recorder) FinalColor(n byte) blink.Color {
+	if n == 0 {
+		n = 1
+	}
+
+	var c blink.Color
+	for _, cmd := range r.Colors() {
+		if cmd.LED == 0 || cmd.LED == n {
+			c = cmd.Color
+		}
+	}
+
+	return c
+}
+
+// AssertColors checks that exactly the expected color commands have been recorded.
+// Set commands and fade commands without duration are considered to be equal.
+func (r *Recorder) AssertColors(t testing.TB, expected ...Command) bool {
+	t.Helper()
+
+	actual := r.Colors()
+	ok := len(actual) == len(expected)
+	for i := 0; ok && i < len(actual); i++ {
+		ok = actual[i].equal(expected[i])
+	}
+
+	if !ok {
+		t.Errorf("unexpected color commands\nexpected:\n%s\nactual:\n%s", formatCommands(expected), formatCommands(actual))
+	}
+
+	return ok
+}
+
+// AssertFinalColor checks that all LEDs have the expected color after all
+// recorded commands have been executed and all fades are complete.
+func (r *Recorder) AssertFinalColor(t testing.TB, expected blink.Color) bool {
+	t.Helper()
+
+	ok := true
+	for n := byte(1); n <= byte(len(r.Emulator.leds)); n++ {
+		if actual := r.FinalColor(n); actual != expected {
+			t.Errorf("led#%d has final color %v instead of %v", n, actual, expected)
+			ok = false
+		}
+	}
+
+	return ok
+}
+
+// AssertTiming checks the time between the recorded color commands, e.g. to
+// verify the timing of a Sequence. The first expected duration is the time
+// between the first and the second color command and so on. Each duration may
+// deviate by the given tolerance.
+// Example:
+//     s := blink.NewSequence().Fade(blink.Red, d).Fade(blink.Blue, d).Off()
+//     s.Play(led)
+//     rec.AssertTiming(t, 20*time.Millisecond, d, d)
+func (r *Recorder) AssertTiming(t testing.TB, tolerance time.Duration, expected ...time.Duration) bool {
+	t.Helper()
+
+	colors := r.Colors()
+	if len(colors) != len(expected)+1 {
+		t.Errorf("expected %d color commands but got %d:\n%s", len(expected)+1, len(colors), formatCommands(colors))
+		return false
+	}
+
+	ok := true
+	for i, d := range expected {
+		actual := colors[i+1].Time.Sub(colors[i].Time)
+		if actual < d-tolerance || actual > d+tolerance {
+			t.Errorf("command %d (%s) was sent %s after the previous command instead of %s±%s", i+1, colors[i+1], actual, d, tolerance)
+			ok = false
+		}
+	}
+
+	return ok
+}
+
+func formatCommands(commands []Command) string {
+	if len(commands) == 0 {
+		return "  (none)"
+	}
+
+	lines := make([]string, len(commands))
+	for i, c := range commands {
+		lines[i] = fmt.Sprintf("  %d: %s", i, c)
+	}
+
+	return strings.Join(lines, "\n")
+}
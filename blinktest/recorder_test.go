@@ -0,0 +1,113 @@
+package blinktest_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fgrosse/blink"
+	"github.com/fgrosse/blink/blinktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingT records the errors of failed assertions instead of failing the test.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorderRecordsCommands(t *testing.T) {
+	clock := blinktest.NewClock()
+	rec := blinktest.NewRecorder()
+	rec.SetClock(clock.Now)
+	led := blink.NewLED(rec)
+
+	require.NoError(t, led.Set(blink.Red))
+	require.NoError(t, led.Fade(blink.Green, 500*time.Millisecond))
+	require.NoError(t, led.Channel(2).Fade(blink.Blue, 0))
+	clock.Advance(time.Second)
+
+	c, err := led.Read()
+	require.NoError(t, err)
+	assert.Equal(t, blink.Green, c)
+
+	commands := rec.Commands()
+	require.Len(t, commands, 5) // including the version query of the Channel and the read
+	assert.Equal(t, byte('n'), commands[0].Op)
+	assert.Equal(t, blink.Red, commands[0].Color)
+	assert.Equal(t, 500*time.Millisecond, commands[1].Duration)
+	assert.Equal(t, byte(2), commands[3].LED)
+	assert.False(t, commands[0].Time.IsZero())
+
+	rec.AssertColors(t,
+		blinktest.Set(blink.Red),
+		blinktest.Fade(blink.Green, 500*time.Millisecond),
+		blinktest.FadeLED(2, blink.Blue, 0),
+	)
+
+	assert.Equal(t, blink.Green, rec.FinalColor(1))
+	assert.Equal(t, blink.Blue, rec.FinalColor(2))
+}
+
+func TestRecorderAssertionsReportFailures(t *testing.T) {
+	rec := blinktest.NewRecorder()
+	led := blink.NewLED(rec)
+	require.NoError(t, led.Set(blink.Red))
+
+	rt := &recordingT{TB: t}
+	assert.False(t, rec.AssertColors(rt, blinktest.Set(blink.Blue)))
+	assert.False(t, rec.AssertFinalColor(rt, blink.Blue))
+	assert.False(t, rec.AssertTiming(rt, time.Millisecond, time.Second))
+	assert.Len(t, rt.errors, 4) // both LEDs have the wrong final color
+
+	rt = &recordingT{TB: t}
+	assert.True(t, rec.AssertColors(rt, blinktest.Fade(blink.Red, 0)), "set and fade without duration are equal")
+	assert.True(t, rec.AssertFinalColor(rt, blink.Red))
+	assert.Empty(t, rt.errors)
+}
+
+func TestRecorderWithSequence(t *testing.T) {
+	rec := blinktest.NewRecorder()
+	led := blink.NewLED(rec)
+
+	d := 50 * time.Millisecond
+	s := blink.NewSequence().
+		Fade(blink.Red, d).
+		Wait(d).
+		Fade(blink.Blue, d).
+		Off()
+
+	require.NoError(t, s.Play(led))
+
+	rec.AssertColors(t,
+		blinktest.Fade(blink.Red, d),
+		blinktest.Fade(blink.Blue, d),
+		blinktest.Set(blink.Color{}),
+	)
+	rec.AssertTiming(t, 40*time.Millisecond, 2*d, d)
+	rec.AssertFinalColor(t, blink.Color{})
+}
+
+func TestRecorderWithClock(t *testing.T) {
+	clock := blinktest.NewClock()
+	rec := blinktest.NewRecorder()
+	rec.SetClock(clock.Now)
+	led := blink.NewLED(rec)
+
+	require.NoError(t, led.Fade(blink.Red, time.Second))
+	clock.Advance(time.Second)
+	require.NoError(t, led.Set(blink.Blue))
+
+	rec.AssertTiming(t, 0, time.Second)
+	assert.Equal(t, blink.Blue, rec.Color(1))
+
+	rec.Reset()
+	assert.Empty(t, rec.Commands())
+}
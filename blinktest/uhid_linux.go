@@ -0,0 +1,281 @@
+//go:build linux && !libusb
+// +build linux,!libusb
+
+package blinktest
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/fgrosse/blink"
+)
+
+// uhidPath is the character device which is used to create virtual HID devices.
+var uhidPath = "/dev/uhid"
+
+// The event types and sizes as defined in linux/uhid.h.
+const (
+	uhidDestroy        = 1
+	uhidGetReport      = 9
+	uhidGetReportReply = 10
+	uhidCreate2        = 11
+	uhidSetReport      = 13
+	uhidSetReportReply = 14
+
+	uhidFeatureReport = 0
+	uhidEventSize     = 4376 // sizeof(struct uhid_event)
+	uhidDataMax       = 4096
+
+	busVirtual = 0x06 // BUS_VIRTUAL as defined in linux/input.h
+)
+
+// reportDescriptor is the HID report descriptor of a blink(1) device.
+// It declares the feature reports 1 and 2 which have 8 and 60 bytes including
+// the report ID, i.e. 7 and 59 bytes of data, like the reports this package sends.
+var reportDescriptor = []byte{
+	0x06, 0xAB, 0xFF, // USAGE_PAGE (Vendor Defined Page 0xFFAB)
+	0x0A, 0x00, 0x20, // USAGE (0x2000)
+	0xA1, 0x01, // COLLECTION (Application)
+	0x15, 0x00, //   LOGICAL_MINIMUM (0)
+	0x26, 0xFF, 0x00, //   LOGICAL_MAXIMUM (255)
+	0x75, 0x08, //   REPORT_SIZE (8)
+	0x85, 0x01, //   REPORT_ID (1)
+	0x95, 0x07, //   REPORT_COUNT (7)
+	0x09, 0x00, //   USAGE (Undefined)
+	0xB2, 0x02, 0x01, //   FEATURE (Data,Var,Abs,Buf)
+	0x75, 0x08, //   REPORT_SIZE (8)
+	0x85, 0x02, //   REPORT_ID (2)
+	0x95, 0x3B, //   REPORT_COUNT (59)
+	0x09, 0x00, //   USAGE (Undefined)
+	0xB2, 0x02, 0x01, //   FEATURE (Data,Var,Abs,Buf)
+	0xC0, // END_COLLECTION
+}
+
+// reportSizes contains the size of each feature report including the report ID.
+// It must agree with the REPORT_COUNT of the reports in reportDescriptor.
+var reportSizes = map[byte]int{1: 8, 2: 60}
+
+// nativeEndian is the byte order which the kernel uses for the fields of uhid events.
+var nativeEndian = func() binary.ByteOrder {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}()
+
+// uhidDevices counts the created devices to give each of them a unique path.
+var uhidDevices uint32
+
+// A UHIDDevice is a virtual blink(1) device which is created via the Linux
+// uhid driver. The kernel treats it like any other HID device, so it is found
+// by blink.List and can be opened via blink.New, which tests the whole path
+// through the kernel including enumeration, permissions and feature reports.
+// All reports are answered by an Emulator.
+//
+// Creating a UHIDDevice needs write access to /dev/uhid, which usually
+// requires root privileges.
+type UHIDDevice struct {
+	Emulator *Emulator
+	Info     blink.DeviceInfo // the device as it is returned by blink.List
+
+	file      *os.File
+	mu        sync.Mutex // serializes writes to file
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewUHIDDevice creates a virtual blink(1) device with the given firmware
+// version and waits until it can be found via blink.List.
+// The caller must call Close to remove the device again.
+// Example:
+//     dev, err := blinktest.NewUHIDDevice(204)
+//     defer dev.Close()
+//
+//     led, err := blink.New(blink.WithPath(dev.Info.Path))
+func NewUHIDDevice(firmware int) (*UHIDDevice, error) {
+	f, err := os.OpenFile(uhidPath, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	n := atomic.AddUint32(&uhidDevices, 1)
+	d := &UHIDDevice{
+		Emulator: NewEmulator(firmware),
+		file:     f,
+		done:     make(chan struct{}),
+	}
+
+	phys := fmt.Sprintf("blinktest-%d-%d", os.Getpid(), n)
+	serial := fmt.Sprintf("BT%06d", n)
+	if err := d.write(createEvent("blink(1) emulator", phys, serial)); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("could not create uhid device: %w", err)
+	}
+
+	go d.serve()
+
+	path := fmt.Sprintf("%.4x:%.4x:%s", blink.VendorNumber, blink.ProductNumber, phys)
+	d.Info, err = waitForDevice(path, 5*time.Second)
+	if err != nil {
+		d.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// waitForDevice polls blink.List until a device with the given path appears.
+// The kernel adds HID devices asynchronously so it takes a moment until a new device can be found.
+func waitForDevice(path string, timeout time.Duration) (blink.DeviceInfo, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		devices, err := blink.List()
+		if err != nil {
+			return blink.DeviceInfo{}, err
+		}
+
+		for _, di := range devices {
+			if di.Path == path {
+				return di, nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return blink.DeviceInfo{}, fmt.Errorf("uhid device %s did not appear within %s", path, timeout)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Close removes the virtual device.
+func (d *UHIDDevice) Close() error {
+	var err error
+	d.closeOnce.Do(func() {
+		ev := make([]byte, uhidEventSize)
+		nativeEndian.PutUint32(ev[0:], uhidDestroy)
+		err = d.write(ev)
+
+		if closeErr := d.file.Close(); err == nil {
+			err = closeErr
+		}
+
+		<-d.done
+	})
+
+	return err
+}
+
+// serve answers the requests of the kernel until the device is closed.
+func (d *UHIDDevice) serve() {
+	defer close(d.done)
+
+	ev := make([]byte, uhidEventSize)
+	for {
+		n, err := d.file.Read(ev)
+		if err != nil {
+			return
+		}
+
+		if n < 4 {
+			continue
+		}
+
+		var reply []byte
+		switch nativeEndian.Uint32(ev[0:]) {
+		case uhidGetReport:
+			reply = d.getReport(ev)
+		case uhidSetReport:
+			reply = d.setReport(ev)
+		default:
+			continue // the device is started, opened or closed
+		}
+
+		if err := d.write(reply); err != nil && !errors.Is(err, os.ErrClosed) {
+			return
+		}
+	}
+}
+
+// getReport answers a UHID_GET_REPORT request by reading the report from the emulator.
+func (d *UHIDDevice) getReport(ev []byte) []byte {
+	id, rnum, rtype := nativeEndian.Uint32(ev[4:]), ev[8], ev[9]
+
+	size, ok := reportSizes[rnum]
+	if !ok || rtype != uhidFeatureReport {
+		return getReportReply(id, syscall.EINVAL, nil)
+	}
+
+	report := make([]byte, size)
+	report[0] = rnum
+	if err := d.Emulator.ReadReport(context.Background(), report); err != nil {
+		return getReportReply(id, syscall.EIO, nil)
+	}
+
+	return getReportReply(id, 0, report)
+}
+
+// setReport answers a UHID_SET_REPORT request by writing the report to the emulator.
+func (d *UHIDDevice) setReport(ev []byte) []byte {
+	id, rtype, size := nativeEndian.Uint32(ev[4:]), ev[9], int(nativeEndian.Uint16(ev[10:]))
+	if rtype != uhidFeatureReport || size > uhidDataMax {
+		return setReportReply(id, syscall.EINVAL)
+	}
+
+	report := append([]byte(nil), ev[12:12+size]...)
+	if err := d.Emulator.WriteReport(context.Background(), report); err != nil {
+		return setReportReply(id, syscall.EIO)
+	}
+
+	return setReportReply(id, 0)
+}
+
+func (d *UHIDDevice) write(ev []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, err := d.file.Write(ev)
+	return err
+}
+
+// createEvent returns a UHID_CREATE2 event for a blink(1) device.
+func createEvent(name, phys, uniq string) []byte {
+	ev := make([]byte, uhidEventSize)
+	nativeEndian.PutUint32(ev[0:], uhidCreate2)
+	copy(ev[4:4+127], name)
+	copy(ev[132:132+63], phys)
+	copy(ev[196:196+63], uniq)
+	nativeEndian.PutUint16(ev[260:], uint16(len(reportDescriptor)))
+	nativeEndian.PutUint16(ev[262:], busVirtual)
+	nativeEndian.PutUint32(ev[264:], blink.VendorNumber)
+	nativeEndian.PutUint32(ev[268:], blink.ProductNumber)
+	copy(ev[280:], reportDescriptor)
+	return ev
+}
+
+func getReportReply(id uint32, errno syscall.Errno, data []byte) []byte {
+	ev := make([]byte, uhidEventSize)
+	nativeEndian.PutUint32(ev[0:], uhidGetReportReply)
+	nativeEndian.PutUint32(ev[4:], id)
+	nativeEndian.PutUint16(ev[8:], uint16(errno))
+	nativeEndian.PutUint16(ev[10:], uint16(len(data)))
+	copy(ev[12:], data)
+	return ev
+}
+
+func setReportReply(id uint32, errno syscall.Errno) []byte {
+	ev := make([]byte, uhidEventSize)
+	nativeEndian.PutUint32(ev[0:], uhidSetReportReply)
+	nativeEndian.PutUint32(ev[4:], id)
+	nativeEndian.PutUint16(ev[8:], uint16(errno))
+	return ev
+}
@@ -0,0 +1,125 @@
+//go:build linux && !libusb
+// +build linux,!libusb
+
+package blinktest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/fgrosse/blink"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateEvent(t *testing.T) {
+	ev := createEvent("blink(1) emulator", "blinktest-1", "BT000001")
+	require.Len(t, ev, uhidEventSize)
+
+	assert.Equal(t, uint32(uhidCreate2), nativeEndian.Uint32(ev[0:]))
+	assert.Equal(t, "blink(1) emulator", string(ev[4:4+17]))
+	assert.Equal(t, "blinktest-1", string(ev[132:132+11]))
+	assert.Equal(t, "BT000001", string(ev[196:196+8]))
+	assert.Equal(t, uint16(len(reportDescriptor)), nativeEndian.Uint16(ev[260:]))
+	assert.Equal(t, uint32(blink.VendorNumber), nativeEndian.Uint32(ev[264:]))
+	assert.Equal(t, uint32(blink.ProductNumber), nativeEndian.Uint32(ev[268:]))
+	assert.Equal(t, reportDescriptor, ev[280:280+len(reportDescriptor)])
+}
+
+func TestReportSizesMatchDescriptor(t *testing.T) {
+	// parse the short items of the descriptor and collect the size of each feature report
+	sizes := map[byte]int{}
+	var id byte
+	var count int
+	for i := 0; i < len(reportDescriptor); {
+		prefix := reportDescriptor[i]
+		n := int(prefix & 0x03)
+		if n == 3 {
+			n = 4
+		}
+		require.LessOrEqual(t, i+1+n, len(reportDescriptor))
+
+		var data int
+		for j := n - 1; j >= 0; j-- {
+			data = data<<8 | int(reportDescriptor[i+1+j])
+		}
+
+		switch prefix & 0xFC {
+		case 0x84: // REPORT_ID
+			id = byte(data)
+		case 0x94: // REPORT_COUNT
+			count = data
+		case 0xB0: // FEATURE
+			sizes[id] = count + 1 // the report ID is sent as first byte
+		}
+
+		i += 1 + n
+	}
+
+	assert.Equal(t, reportSizes, sizes)
+}
+
+func TestReportReplies(t *testing.T) {
+	d := &UHIDDevice{Emulator: NewEmulator(204)}
+
+	set := make([]byte, uhidEventSize)
+	nativeEndian.PutUint32(set[0:], uhidSetReport)
+	nativeEndian.PutUint32(set[4:], 42)
+	nativeEndian.PutUint16(set[10:], 8)
+	copy(set[12:], []byte{0x01, 'n', 0xff, 0x00, 0x00, 0x00, 0x00, 0x00})
+
+	reply := d.setReport(set)
+	assert.Equal(t, uint32(uhidSetReportReply), nativeEndian.Uint32(reply[0:]))
+	assert.Equal(t, uint32(42), nativeEndian.Uint32(reply[4:]))
+	assert.Equal(t, uint16(0), nativeEndian.Uint16(reply[8:]))
+	assert.Equal(t, blink.Red, d.Emulator.Color(1))
+
+	get := make([]byte, uhidEventSize)
+	nativeEndian.PutUint32(get[0:], uhidGetReport)
+	nativeEndian.PutUint32(get[4:], 43)
+	get[8] = 1
+
+	reply = d.getReport(get)
+	assert.Equal(t, uint32(uhidGetReportReply), nativeEndian.Uint32(reply[0:]))
+	assert.Equal(t, uint32(43), nativeEndian.Uint32(reply[4:]))
+	assert.Equal(t, uint16(0), nativeEndian.Uint16(reply[8:]))
+	assert.Equal(t, uint16(8), nativeEndian.Uint16(reply[10:]))
+	assert.Equal(t, []byte{0x01, 'n', 0xff, 0x00, 0x00, 0x00, 0x00, 0x00}, reply[12:20])
+
+	get[8] = 3 // unknown report
+	reply = d.getReport(get)
+	assert.NotZero(t, nativeEndian.Uint16(reply[8:]))
+}
+
+func TestUHIDDevice(t *testing.T) {
+	if _, err := os.Stat(uhidPath); err != nil {
+		t.Skipf("uhid is not available: %s", err)
+	}
+
+	dev, err := NewUHIDDevice(204)
+	if os.IsPermission(err) {
+		t.Skipf("uhid is not available: %s", err)
+	}
+	require.NoError(t, err)
+	defer dev.Close()
+
+	led, err := blink.New(blink.WithPath(dev.Info.Path))
+	require.NoError(t, err)
+	defer led.Close()
+
+	assert.Equal(t, dev.Info, led.Info())
+	assert.Equal(t, "BT", led.Info().Serial[:2])
+
+	v, err := led.Version()
+	require.NoError(t, err)
+	assert.Equal(t, 204, v)
+
+	require.NoError(t, led.SetEach(blink.Red, blink.Blue))
+	assert.Equal(t, blink.Blue, dev.Emulator.Color(2))
+
+	c, err := led.Channel(1).Read()
+	require.NoError(t, err)
+	assert.Equal(t, blink.Red, c)
+
+	assert.Error(t, led.WriteNote(0, []byte("not a mk3")))
+}
@@ -0,0 +1,151 @@
+package blink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Generation identifies the hardware generation of a blink(1) device.
+type Generation int
+
+// The known blink(1) hardware generations.
+const (
+	MK1 Generation = 1
+	MK2 Generation = 2
+	MK3 Generation = 3
+)
+
+// String implements fmt.Stringer.
+func (g Generation) String() string {
+	return fmt.Sprintf("mk%d", int(g))
+}
+
+// Capabilities describes which features are supported by a blink(1) device.
+type Capabilities struct {
+	Generation       Generation // the hardware generation of the device
+	Firmware         int        // the firmware version, e.g. 204 for v2.04
+	LEDs             int        // the number of LEDs of the device
+	PerLEDAddressing bool       // whether each LED can be controlled individually (see LED.ID)
+	Readback         bool       // whether the current color can be read back from the device
+	PatternLines     int        // the number of lines in the color pattern table of the device
+	FlashSave        bool       // whether the color pattern can be saved to flash
+}
+
+// capabilitiesForFirmware returns the capabilities of a device with the given firmware version.
+// The major version of the firmware matches the hardware generation.
+func capabilitiesForFirmware(firmware int) Capabilities {
+	switch major := firmware / 100; {
+	case major <= 1:
+		return Capabilities{
+			Generation:   MK1,
+			Firmware:     firmware,
+			LEDs:         1,
+			PatternLines: 12,
+		}
+	default:
+		g := MK2
+		if major >= 3 {
+			g = MK3
+		}
+
+		return Capabilities{
+			Generation:       g,
+			Firmware:         firmware,
+			LEDs:             2,
+			PerLEDAddressing: true,
+			Readback:         true,
+			PatternLines:     32,
+			FlashSave:        true,
+		}
+	}
+}
+
+// ErrUnsupported is returned if an operation is not supported by the connected device.
+// The actual error is an UnsupportedError so use errors.Is(err, ErrUnsupported) to check for it.
+var ErrUnsupported = errors.New("operation is not supported by the blink1 device")
+
+// An UnsupportedError is returned if an operation is not supported by the
+// hardware generation of the connected device.
+type UnsupportedError struct {
+	Op         string     // the operation that was attempted
+	Generation Generation // the generation of the connected device
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s is not supported by blink1 %s devices", e.Op, e.Generation)
+}
+
+// Is makes an UnsupportedError match ErrUnsupported via errors.Is.
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+// Version returns the firmware version of the device, e.g. 204 for v2.04.
+func (l *LED) Version() (int, error) {
+	return l.VersionContext(context.Background())
+}
+
+// VersionContext is like Version but aborts when ctx is done.
+func (l *LED) VersionContext(ctx context.Context) (int, error) {
+	buf, err := l.read(ctx, &versionCommand{})
+	if err != nil {
+		return 0, err
+	}
+
+	if !isDigit(buf[3]) || !isDigit(buf[4]) {
+		return 0, fmt.Errorf("invalid firmware version in response % x", buf)
+	}
+
+	major, minor := int(buf[3]-'0'), int(buf[4]-'0')
+	return major*100 + minor, nil
+}
+
+// Capabilities returns the features that are supported by the device.
+// The capabilities are derived from the firmware version which is read from
+// the device once and then cached.
+func (l *LED) Capabilities() (Capabilities, error) {
+	return l.CapabilitiesContext(context.Background())
+}
+
+// CapabilitiesContext is like Capabilities but aborts when ctx is done.
+func (l *LED) CapabilitiesContext(ctx context.Context) (Capabilities, error) {
+	l.mu.Lock()
+	cached := l.caps
+	l.mu.Unlock()
+
+	if cached != nil {
+		return *cached, nil
+	}
+
+	v, err := l.VersionContext(ctx)
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("could not read firmware version: %w", err)
+	}
+
+	caps := capabilitiesForFirmware(v)
+
+	l.mu.Lock()
+	l.caps = &caps
+	l.mu.Unlock()
+
+	return caps, nil
+}
+
+// require returns an UnsupportedError for the given operation if supported returns false.
+func (l *LED) require(ctx context.Context, op string, supported func(Capabilities) bool) error {
+	caps, err := l.CapabilitiesContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !supported(caps) {
+		return &UnsupportedError{Op: op, Generation: caps.Generation}
+	}
+
+	return nil
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
@@ -0,0 +1,79 @@
+package blink
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersion(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{'v': mk2Version}}
+	v, err := NewLED(tr).Version()
+	require.NoError(t, err)
+	assert.Equal(t, 204, v)
+}
+
+func TestMalformedVersion(t *testing.T) {
+	for _, response := range [][]byte{
+		{0x01, 'v', 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		{0x01, 'v', 0x00, '2', 'x', 0x00, 0x00, 0x00},
+	} {
+		led := NewLED(&fakeTransport{responses: map[byte][]byte{'v': response}})
+
+		_, err := led.Version()
+		assert.Error(t, err)
+
+		_, err = led.Capabilities()
+		assert.Error(t, err, "a malformed version must not be classified as any generation")
+	}
+}
+
+func TestCapabilitiesForFirmware(t *testing.T) {
+	assert.Equal(t, MK1, capabilitiesForFirmware(105).Generation)
+	assert.Equal(t, MK2, capabilitiesForFirmware(204).Generation)
+	assert.Equal(t, MK3, capabilitiesForFirmware(302).Generation)
+
+	mk1 := capabilitiesForFirmware(105)
+	assert.False(t, mk1.Readback)
+	assert.False(t, mk1.PerLEDAddressing)
+	assert.Equal(t, 12, mk1.PatternLines)
+
+	mk2 := capabilitiesForFirmware(204)
+	assert.True(t, mk2.Readback)
+	assert.True(t, mk2.PerLEDAddressing)
+	assert.True(t, mk2.FlashSave)
+	assert.Equal(t, 32, mk2.PatternLines)
+}
+
+func TestCapabilitiesAreCached(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{'v': mk2Version}}
+	led := NewLED(tr)
+
+	_, err := led.Capabilities()
+	require.NoError(t, err)
+	caps, err := led.Capabilities()
+	require.NoError(t, err)
+
+	assert.Equal(t, 204, caps.Firmware)
+	assert.Len(t, tr.written, 1)
+}
+
+func TestUnsupportedOperationsOnMK1(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{'v': {0x01, 'v', 0x00, '1', '5', 0x00, 0x00, 0x00}}}
+	led := NewLED(tr)
+
+	_, err := led.Read()
+	assert.True(t, errors.Is(err, ErrUnsupported))
+	assert.EqualError(t, err, "reading the current color is not supported by blink1 mk1 devices")
+
+	led.ID = 1
+	err = led.Fade(Red, time.Second)
+	assert.True(t, errors.Is(err, ErrUnsupported))
+
+	led.ID = 0
+	assert.NoError(t, led.Fade(Red, time.Second))
+	assert.Len(t, tr.written, 2, "only the version request and the last fade should have been sent")
+}
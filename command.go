@@ -48,3 +48,216 @@ func (c *readRGBCommand) bytes() []byte {
 		c.n,
 	}
 }
+
+type versionCommand struct{}
+
+func (c *versionCommand) bytes() []byte {
+	return []byte{reportID,
+		'v',
+		0, 0, 0,
+		0, 0, 0,
+	}
+}
+
+type serverdownCommand struct {
+	on         bool          // whether to arm or disarm the watchdog
+	timeout    time.Duration // time without tickle until the pattern starts playing
+	stayLit    bool          // keep the current color when the watchdog is disarmed (mk2 only)
+	start, end byte          // the range of pattern lines to play (mk2 only)
+}
+
+func (c *serverdownCommand) bytes() []byte {
+	t := c.timeout.Nanoseconds() / 1E7
+	return []byte{reportID,
+		'D',
+		boolByte(c.on),
+		byte(t >> 8), byte(t & 0xff),
+		boolByte(c.stayLit),
+		c.start, c.end,
+	}
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type playCommand struct {
+	play       bool // whether to start or stop playing
+	start, end byte // the range of pattern lines to play (end is mk2 only)
+	count      byte // how often to play the range, 0=forever (mk2 only)
+}
+
+func (c *playCommand) bytes() []byte {
+	return []byte{reportID,
+		'p',
+		boolByte(c.play),
+		c.start, c.end,
+		c.count,
+		0, 0,
+	}
+}
+
+type readPlayStateCommand struct{}
+
+func (c *readPlayStateCommand) bytes() []byte {
+	return []byte{reportID,
+		'S',
+		0, 0, 0,
+		0, 0, 0,
+	}
+}
+
+type setPatternLineCommand struct {
+	Color                  // 24-bit RGB color
+	duration time.Duration // how long the fade to this color should last
+	pos      byte          // the position of the line in the pattern table
+}
+
+func (c *setPatternLineCommand) bytes() []byte {
+	t := c.duration.Nanoseconds() / 1E7
+	return []byte{reportID,
+		'P',
+		c.R, c.G, c.B,
+		byte(t >> 8), byte(t & 0xff),
+		c.pos,
+	}
+}
+
+type readPatternLineCommand struct {
+	pos byte // the position of the line in the pattern table
+}
+
+func (c *readPatternLineCommand) bytes() []byte {
+	return []byte{reportID,
+		'R',
+		0, 0, 0,
+		0, 0,
+		c.pos,
+	}
+}
+
+type setLEDCommand struct {
+	n byte // which LED the next pattern line addresses: 0=all, 1=led#1, 2=led#2, etc. (mk2 only)
+}
+
+func (c *setLEDCommand) bytes() []byte {
+	return []byte{reportID,
+		'l',
+		c.n,
+		0, 0,
+		0, 0, 0,
+	}
+}
+
+type savePatternsCommand struct{}
+
+func (c *savePatternsCommand) bytes() []byte {
+	// the magic bytes protect against accidentally overwriting the flash
+	return []byte{reportID,
+		'W',
+		0xBE, 0xEF, 0xCA, 0xFE,
+		0, 0,
+	}
+}
+
+type readEEPROMCommand struct {
+	addr byte
+}
+
+func (c *readEEPROMCommand) bytes() []byte {
+	return []byte{reportID,
+		'e',
+		c.addr,
+		0, 0,
+		0, 0, 0,
+	}
+}
+
+type writeEEPROMCommand struct {
+	addr, val byte
+}
+
+func (c *writeEEPROMCommand) bytes() []byte {
+	return []byte{reportID,
+		'E',
+		c.addr, c.val,
+		0,
+		0, 0, 0,
+	}
+}
+
+type testCommand struct{}
+
+func (c *testCommand) bytes() []byte {
+	return []byte{reportID,
+		'!',
+		0, 0, 0,
+		0, 0, 0,
+	}
+}
+
+// reportID2 is the ID of the larger feature report which is used by mk3 devices
+// to transfer user notes and the chip ID.
+const (
+	reportID2   = 0x02
+	report2Size = 60
+)
+
+type startupParamsCommand struct {
+	mode       BootMode
+	start, end byte // the range of pattern lines to play at startup
+	count      byte // how often to play the range, 0=forever
+}
+
+func (c *startupParamsCommand) bytes() []byte {
+	return []byte{reportID,
+		'B',
+		byte(c.mode),
+		c.start, c.end,
+		c.count,
+		0, 0,
+	}
+}
+
+type readStartupParamsCommand struct{}
+
+func (c *readStartupParamsCommand) bytes() []byte {
+	return []byte{reportID,
+		'b',
+		0, 0, 0,
+		0, 0, 0,
+	}
+}
+
+type writeNoteCommand struct {
+	id   byte
+	data []byte // at most NoteSize bytes
+}
+
+func (c *writeNoteCommand) bytes() []byte {
+	buf := make([]byte, report2Size)
+	buf[0], buf[1], buf[2] = reportID2, 'F', c.id
+	copy(buf[3:3+NoteSize], c.data)
+	return buf
+}
+
+type readNoteCommand struct {
+	id byte
+}
+
+func (c *readNoteCommand) bytes() []byte {
+	buf := make([]byte, report2Size)
+	buf[0], buf[1], buf[2] = reportID2, 'f', c.id
+	return buf
+}
+
+type readChipIDCommand struct{}
+
+func (c *readChipIDCommand) bytes() []byte {
+	buf := make([]byte, report2Size)
+	buf[0], buf[1] = reportID2, 'U'
+	return buf
+}
@@ -25,3 +25,95 @@ func TestReadRGBCommand(t *testing.T) {
 	c := readRGBCommand{n: 1}
 	assert.Equal(t, []byte{0x01, 'r', 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}, c.bytes())
 }
+
+func TestVersionCommand(t *testing.T) {
+	c := versionCommand{}
+	assert.Equal(t, []byte{0x01, 'v', 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, c.bytes())
+}
+
+func TestServerdownCommand(t *testing.T) {
+	c := serverdownCommand{on: true, timeout: 5 * time.Second, start: 2, end: 5}
+	assert.Equal(t, []byte{0x01, 'D', 0x01, 0x01, 0xf4, 0x00, 0x02, 0x05}, c.bytes())
+
+	c = serverdownCommand{on: false, stayLit: true}
+	assert.Equal(t, []byte{0x01, 'D', 0x00, 0x00, 0x00, 0x01, 0x00, 0x00}, c.bytes())
+}
+
+func TestPlayCommand(t *testing.T) {
+	c := playCommand{play: true, start: 2, end: 5, count: 3}
+	assert.Equal(t, []byte{0x01, 'p', 0x01, 0x02, 0x05, 0x03, 0x00, 0x00}, c.bytes())
+
+	c = playCommand{play: false}
+	assert.Equal(t, []byte{0x01, 'p', 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, c.bytes())
+}
+
+func TestReadPlayStateCommand(t *testing.T) {
+	c := readPlayStateCommand{}
+	assert.Equal(t, []byte{0x01, 'S', 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, c.bytes())
+}
+
+func TestSetPatternLineCommand(t *testing.T) {
+	c := setPatternLineCommand{Color: Color{0x11, 0x22, 0x33}, duration: 3 * time.Second, pos: 5}
+	assert.Equal(t, []byte{0x01, 'P', 0x11, 0x22, 0x33, 0x01, 0x2c, 0x05}, c.bytes())
+}
+
+func TestReadPatternLineCommand(t *testing.T) {
+	c := readPatternLineCommand{pos: 7}
+	assert.Equal(t, []byte{0x01, 'R', 0x00, 0x00, 0x00, 0x00, 0x00, 0x07}, c.bytes())
+}
+
+func TestSetLEDCommand(t *testing.T) {
+	c := setLEDCommand{n: 2}
+	assert.Equal(t, []byte{0x01, 'l', 0x02, 0x00, 0x00, 0x00, 0x00, 0x00}, c.bytes())
+}
+
+func TestSavePatternsCommand(t *testing.T) {
+	c := savePatternsCommand{}
+	assert.Equal(t, []byte{0x01, 'W', 0xBE, 0xEF, 0xCA, 0xFE, 0x00, 0x00}, c.bytes())
+}
+
+func TestReadEEPROMCommand(t *testing.T) {
+	c := readEEPROMCommand{addr: 0x2a}
+	assert.Equal(t, []byte{0x01, 'e', 0x2a, 0x00, 0x00, 0x00, 0x00, 0x00}, c.bytes())
+}
+
+func TestWriteEEPROMCommand(t *testing.T) {
+	c := writeEEPROMCommand{addr: 0x2a, val: 0x99}
+	assert.Equal(t, []byte{0x01, 'E', 0x2a, 0x99, 0x00, 0x00, 0x00, 0x00}, c.bytes())
+}
+
+func TestTestCommand(t *testing.T) {
+	c := testCommand{}
+	assert.Equal(t, []byte{0x01, '!', 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, c.bytes())
+}
+
+func TestStartupParamsCommand(t *testing.T) {
+	c := startupParamsCommand{mode: BootPlay, start: 1, end: 4, count: 2}
+	assert.Equal(t, []byte{0x01, 'B', 0x01, 0x01, 0x04, 0x02, 0x00, 0x00}, c.bytes())
+}
+
+func TestReadStartupParamsCommand(t *testing.T) {
+	c := readStartupParamsCommand{}
+	assert.Equal(t, []byte{0x01, 'b', 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, c.bytes())
+}
+
+func TestWriteNoteCommand(t *testing.T) {
+	c := writeNoteCommand{id: 3, data: []byte("hi")}
+	b := c.bytes()
+	assert.Len(t, b, 60)
+	assert.Equal(t, []byte{0x02, 'F', 0x03, 'h', 'i', 0x00}, b[:6])
+}
+
+func TestReadNoteCommand(t *testing.T) {
+	c := readNoteCommand{id: 3}
+	b := c.bytes()
+	assert.Len(t, b, 60)
+	assert.Equal(t, []byte{0x02, 'f', 0x03, 0x00}, b[:4])
+}
+
+func TestReadChipIDCommand(t *testing.T) {
+	c := readChipIDCommand{}
+	b := c.bytes()
+	assert.Len(t, b, 60)
+	assert.Equal(t, []byte{0x02, 'U', 0x00}, b[:3])
+}
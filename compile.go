@@ -0,0 +1,286 @@
+package blink
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxLineDuration is the longest fade duration that can be stored in a pattern line.
+const maxLineDuration = 0xFFFF * 10 * time.Millisecond
+
+// maxCompiledLines limits the number of lines a Sequence can be compiled to.
+// Positions in the pattern table are addressed by a single byte.
+const maxCompiledLines = 256
+
+// A Pattern is a color pattern that is played by the device itself.
+// The device plays the lines from Start to End (inclusive) Count times.
+// If Count is zero the lines are played forever.
+type Pattern struct {
+	Lines      []PatternLine
+	Start, End int
+	Count      int
+}
+
+// A CompileError is returned if a Sequence contains frames that can not be
+// represented as a Pattern.
+type CompileError struct {
+	Frames []FrameError
+}
+
+// A FrameError describes why a single frame of a Sequence can not be compiled.
+type FrameError struct {
+	Frame  int    // the index of the frame in the sequence
+	Reason string // why the frame can not be compiled
+}
+
+func (e *CompileError) Error() string {
+	msgs := make([]string, len(e.Frames))
+	for i, f := range e.Frames {
+		msgs[i] = fmt.Sprintf("frame %d: %s", f.Frame, f.Reason)
+	}
+
+	return "could not compile sequence: " + strings.Join(msgs, "; ")
+}
+
+// Compile converts the sequence into a Pattern that can be written to the
+// device via LED.WritePattern and then played via LED.PlayPatternLoop.
+//
+// Finite loops are unrolled into the pattern lines. A sequence may contain a
+// single infinite Loop as its last frame, which is then played by the device.
+// All frames which can not be represented as pattern lines, such as FadeFunc
+// frames or frames before a Start that is followed by an infinite Loop,
+// are reported via a *CompileError.
+// Durations are stored in steps of 10ms.
+// Example:
+//     p, err := seq.Compile()
+//     err = led.WritePattern(p)
+//     err = led.PlayPatternLoop(p.Start, p.End, p.Count)
+func (s *Sequence) Compile() (*Pattern, error) {
+	c := &compiler{
+		counters: map[*loopFrame]int{},
+		failed:   map[int]bool{},
+	}
+
+	p := c.compile(s.frames)
+	if len(c.errs) > 0 {
+		return nil, &CompileError{Frames: c.errs}
+	}
+
+	return p, nil
+}
+
+// compiler simulates the playback of a sequence and records the pattern
+// lines that the device would need to play.
+type compiler struct {
+	lines    []PatternLine
+	counters map[*loopFrame]int // the remaining loops of each loop frame
+	errs     []FrameError
+	failed   map[int]bool // frames that have already been reported
+}
+
+func (c *compiler) compile(frames []frame) *Pattern {
+	var (
+		base      int // the frame at which a loop restarts the sequence
+		startLine int // the first line that is played when a loop restarts the sequence
+	)
+
+	for i := 0; i < len(frames); i++ {
+		if len(c.lines) > maxCompiledLines {
+			c.fail(i, fmt.Sprintf("the sequence needs more than %d pattern lines", maxCompiledLines))
+			return nil
+		}
+
+		switch f := frames[i].(type) {
+		case *cmdFrame:
+			c.command(i, f)
+		case *waitFrame:
+			c.wait(i, f.Duration)
+		case *fadeFuncFrame:
+			c.fail(i, "colors calculated by FadeFunc can not be stored in pattern lines")
+		case *startFrame:
+			base, startLine = i+1, len(c.lines)
+		case *loopFrame:
+			n, ok := c.counters[f]
+			if !ok {
+				n = f.n
+			}
+
+			if n < 0 {
+				return c.infiniteLoop(frames, i, startLine)
+			}
+
+			if n > 0 {
+				n--
+			}
+
+			c.counters[f] = n
+			if n != 0 {
+				i = base - 1 // works because i is always incremented after each frame
+			}
+		default:
+			c.fail(i, fmt.Sprintf("unknown frame type %T", f))
+		}
+	}
+
+	return &Pattern{Lines: c.lines, Start: 0, End: len(c.lines) - 1, Count: 1}
+}
+
+// infiniteLoop finishes the pattern at the infinite loop frame at index i.
+func (c *compiler) infiniteLoop(frames []frame, i, startLine int) *Pattern {
+	if startLine > 0 {
+		c.fail(i, "the device can not play the frames before the Start of an infinite loop only once")
+	}
+
+	for j := i + 1; j < len(frames); j++ {
+		c.fail(j, "frames after an infinite loop can not be played by the device")
+	}
+
+	if startLine == len(c.lines) {
+		c.fail(i, "the infinite loop does not contain any colors")
+	}
+
+	return &Pattern{Lines: c.lines, Start: startLine, End: len(c.lines) - 1, Count: 0}
+}
+
+func (c *compiler) command(i int, f *cmdFrame) {
+	d := f.Duration
+	switch cmd := f.command.(type) {
+	case *setRGBCommand:
+		c.line(i, PatternLine{Color: cmd.Color, LEDIndex: f.led})
+	case *fadeRGBCommand:
+		if cmd.duration > d {
+			c.fail(i, "the fade lasts longer than the frame")
+			return
+		}
+
+		c.line(i, PatternLine{Color: cmd.Color, FadeDuration: cmd.duration, LEDIndex: f.led})
+		d -= cmd.duration
+	default:
+		c.fail(i, fmt.Sprintf("unsupported command %T", cmd))
+		return
+	}
+
+	if d > 0 {
+		c.wait(i, d)
+	}
+}
+
+// wait adds a line which keeps the color of the previous line for the duration d.
+func (c *compiler) wait(i int, d time.Duration) {
+	if len(c.lines) == 0 {
+		c.fail(i, "the device can not wait before the first color has been set")
+		return
+	}
+
+	line := c.lines[len(c.lines)-1]
+	line.FadeDuration = d
+	c.line(i, line)
+}
+
+func (c *compiler) line(i int, line PatternLine) {
+	if line.FadeDuration > maxLineDuration {
+		c.fail(i, fmt.Sprintf("durations longer than %s can not be stored in pattern lines", maxLineDuration))
+		return
+	}
+
+	c.lines = append(c.lines, line)
+}
+
+// fail records that the frame at index i can not be compiled.
+// Each frame is reported at most once, even if it is visited multiple times in a loop.
+func (c *compiler) fail(i int, reason string) {
+	if c.failed[i] {
+		return
+	}
+
+	c.failed[i] = true
+	c.errs = append(c.errs, FrameError{Frame: i, Reason: reason})
+}
+
+// Sequence converts the pattern back into a Sequence that plays the lines
+// from Start to End the same way the device does.
+func (p *Pattern) Sequence() *Sequence {
+	s := NewSequence()
+	for i := p.Start; i <= p.End && i < len(p.Lines); i++ {
+		line := p.Lines[i]
+		s.FadeLED(line.LEDIndex, line.Color, line.FadeDuration)
+	}
+
+	switch {
+	case p.Count == 0:
+		s.frames = append(s.frames, &loopFrame{seq: s, n: -1})
+	case p.Count > 1:
+		s.LoopN(p.Count)
+	}
+
+	return s
+}
+
+// WritePattern writes the lines of the given pattern to the color pattern
+// table of the device, starting at the first line. Use SavePatterns to keep
+// the pattern after a power cycle.
+func (l *LED) WritePattern(p *Pattern) error {
+	return l.WritePatternContext(context.Background(), p)
+}
+
+// WritePatternContext is like WritePattern but aborts when ctx is done.
+func (l *LED) WritePatternContext(ctx context.Context, p *Pattern) error {
+	caps, err := l.CapabilitiesContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(p.Lines) > caps.PatternLines {
+		return fmt.Errorf("pattern has %d lines but the device can only store %d", len(p.Lines), caps.PatternLines)
+	}
+
+	for i, line := range p.Lines {
+		if err := l.SetPatternLineContext(ctx, i, line); err != nil {
+			return fmt.Errorf("could not write pattern line %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// ReadPattern reads the color pattern table of the device.
+// For devices that support reading the play state the loop bounds of the
+// pattern are read from the device as well. Otherwise the pattern contains
+// all lines and loops forever, which is what mk1 devices do.
+// Use Pattern.Sequence to convert the pattern into a Sequence.
+func (l *LED) ReadPattern() (*Pattern, error) {
+	return l.ReadPatternContext(context.Background())
+}
+
+// ReadPatternContext is like ReadPattern but aborts when ctx is done.
+func (l *LED) ReadPatternContext(ctx context.Context) (*Pattern, error) {
+	caps, err := l.CapabilitiesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Pattern{
+		Lines: make([]PatternLine, caps.PatternLines),
+		End:   caps.PatternLines - 1,
+	}
+
+	for i := range p.Lines {
+		p.Lines[i], err = l.ReadPatternLineContext(ctx, i)
+		if err != nil {
+			return nil, fmt.Errorf("could not read pattern line %d: %w", i, err)
+		}
+	}
+
+	if caps.Generation >= MK2 {
+		state, err := l.PlayStateContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		p.Start, p.End, p.Count = state.Start, state.End, state.Count
+	}
+
+	return p, nil
+}
@@ -0,0 +1,169 @@
+package blink
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	d := 500 * time.Millisecond
+	s := NewSequence().
+		Fade(Red, d).
+		Set(Blue, time.Second).
+		Wait(d).
+		Off()
+
+	p, err := s.Compile()
+	require.NoError(t, err)
+	assert.Equal(t, &Pattern{
+		Lines: []PatternLine{
+			{Color: Red, FadeDuration: d},
+			{Color: Blue},
+			{Color: Blue, FadeDuration: time.Second},
+			{Color: Blue, FadeDuration: d},
+			{Color: Color{}},
+		},
+		Start: 0, End: 4, Count: 1,
+	}, p)
+}
+
+func TestCompileLoops(t *testing.T) {
+	d := 100 * time.Millisecond
+	s := NewSequence().
+		Fade(Red, d).
+		LoopN(2).
+		Start().
+		Fade(Green, d).
+		Fade(Blue, d).
+		LoopN(2)
+
+	p, err := s.Compile()
+	require.NoError(t, err)
+	assert.Equal(t, []PatternLine{
+		{Color: Red, FadeDuration: d},
+		{Color: Red, FadeDuration: d},
+		{Color: Green, FadeDuration: d},
+		{Color: Blue, FadeDuration: d},
+		{Color: Green, FadeDuration: d},
+		{Color: Blue, FadeDuration: d},
+	}, p.Lines)
+	assert.Equal(t, 0, p.Start)
+	assert.Equal(t, 5, p.End)
+	assert.Equal(t, 1, p.Count)
+
+	// the sequence itself must not be changed by compiling it
+	assert.Len(t, s.frames, 6)
+	assert.Equal(t, 2, s.frames[1].(*loopFrame).n)
+}
+
+func TestCompileInfiniteLoop(t *testing.T) {
+	d := 100 * time.Millisecond
+	s, _ := NewSequence().
+		Fade(Red, d).
+		Fade(Blue, d).
+		Loop()
+
+	p, err := s.Compile()
+	require.NoError(t, err)
+	assert.Equal(t, &Pattern{
+		Lines: []PatternLine{
+			{Color: Red, FadeDuration: d},
+			{Color: Blue, FadeDuration: d},
+		},
+		Start: 0, End: 1, Count: 0,
+	}, p)
+}
+
+func TestCompileErrors(t *testing.T) {
+	d := 100 * time.Millisecond
+	s, _ := NewSequence().
+		Wait(d).
+		Fade(Red, d).
+		FadeFunc(RandomColor, d).
+		Start().
+		Fade(Blue, d).
+		Loop()
+	s.Fade(Green, d)
+
+	_, err := s.Compile()
+	require.Error(t, err)
+
+	var compileErr *CompileError
+	require.True(t, errors.As(err, &compileErr))
+
+	var frames []int
+	for _, f := range compileErr.Frames {
+		frames = append(frames, f.Frame)
+	}
+	assert.Equal(t, []int{0, 2, 5, 6}, frames)
+}
+
+func TestPatternSequence(t *testing.T) {
+	d := 100 * time.Millisecond
+	p := &Pattern{
+		Lines: []PatternLine{
+			{Color: Color{}},
+			{Color: Red, FadeDuration: d},
+			{Color: Blue, FadeDuration: d, LEDIndex: 2},
+		},
+		Start: 1, End: 2, Count: 3,
+	}
+
+	s := p.Sequence()
+	require.Len(t, s.frames, 3)
+	assert.Equal(t, &cmdFrame{command: &fadeRGBCommand{Color: Red, duration: d}, Duration: d}, s.frames[0])
+	assert.Equal(t, &cmdFrame{command: &fadeRGBCommand{Color: Blue, duration: d}, Duration: d, led: 2}, s.frames[1])
+
+	compiled, err := s.Compile()
+	require.NoError(t, err)
+	assert.Len(t, compiled.Lines, 6)
+}
+
+func TestWritePattern(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{'v': mk1Version}}
+	led := NewLED(tr)
+
+	p := &Pattern{Lines: []PatternLine{{Color: Red}, {Color: Blue, FadeDuration: time.Second}}}
+	require.NoError(t, led.WritePattern(p))
+	assert.Equal(t, [][]byte{
+		{0x01, 'P', 0xff, 0x00, 0x00, 0x00, 0x00, 0x00},
+		{0x01, 'P', 0x00, 0x00, 0xff, 0x00, 0x64, 0x01},
+	}, tr.written[1:])
+
+	p.Lines = make([]PatternLine, 13)
+	assert.Error(t, led.WritePattern(p))
+}
+
+func TestReadPattern(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{
+		'v': mk2Version,
+		'R': {0x01, 'R', 0xff, 0x00, 0x00, 0x00, 0x0a, 0x00},
+		'S': {0x01, 'S', 0x00, 0x02, 0x05, 0x03, 0x00, 0x00},
+	}}
+
+	p, err := NewLED(tr).ReadPattern()
+	require.NoError(t, err)
+	assert.Len(t, p.Lines, 32)
+	assert.Equal(t, PatternLine{Color: Red, FadeDuration: 100 * time.Millisecond}, p.Lines[31])
+	assert.Equal(t, 2, p.Start)
+	assert.Equal(t, 5, p.End)
+	assert.Equal(t, 3, p.Count)
+}
+
+func TestCompileLEDFrames(t *testing.T) {
+	s := NewSequence().
+		SetLED(1, Red, time.Second).
+		FadeLED(2, Blue, 100*time.Millisecond)
+
+	p, err := s.Compile()
+	require.NoError(t, err)
+	assert.Equal(t, []PatternLine{
+		{Color: Red, LEDIndex: 1},
+		{Color: Red, FadeDuration: time.Second, LEDIndex: 1},
+		{Color: Blue, FadeDuration: 100 * time.Millisecond, LEDIndex: 2},
+	}, p.Lines)
+}
@@ -0,0 +1,80 @@
+package blink
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// A Device is any light that can be controlled by this package, e.g. an LED,
+// a single Channel of an LED or a Group of LEDs. Sequences can be played on
+// all implementations of Device, which includes fakes and remote devices.
+type Device interface {
+	// Set lights up the device with the specified color immediately.
+	Set(Color) error
+
+	// Fade lets the device fade to the specified color over the given duration.
+	Fade(Color, time.Duration) error
+
+	// Read reads the currently active color of the device.
+	Read() (Color, error)
+
+	io.Closer
+}
+
+// A ContextDevice is a Device whose methods can be aborted via a context.
+// Sequence.PlayContext uses these methods if the device implements them.
+type ContextDevice interface {
+	Device
+	SetContext(context.Context, Color) error
+	FadeContext(context.Context, Color, time.Duration) error
+}
+
+// The devices of this package.
+var (
+	_ ContextDevice = new(LED)
+	_ ContextDevice = new(Group)
+)
+
+// DeviceInfo describes a connected blink(1) device.
+type DeviceInfo struct {
+	Path      string // the USB bus path in the form "<vendor>:<product>:<port numbers>", e.g. "27b8:01ed:02.04"
+	VendorID  uint16 // the USB vendor identifier (see VendorNumber)
+	ProductID uint16 // the USB product identifier (see ProductNumber)
+	Serial    string // the USB serial number string
+	Product   string // the USB product string, e.g. "blink(1) mk2"
+}
+
+// String returns a human readable single line representation of the device
+// which is suitable to be printed by command line tools.
+func (di DeviceInfo) String() string {
+	return fmt.Sprintf("%s serial=%s product=%q", di.Path, di.Serial, di.Product)
+}
+
+func (di DeviceInfo) isBlink1() bool {
+	return di.VendorID == VendorNumber && di.ProductID == ProductNumber
+}
+
+// List returns information about all locally connected blink(1) devices.
+// The devices are sorted by their Path.
+func List() ([]DeviceInfo, error) {
+	all, err := usbDevices()
+	if err != nil {
+		return nil, fmt.Errorf("could not list USB devices: %s", err)
+	}
+
+	var devices []DeviceInfo
+	for _, di := range all {
+		if di.isBlink1() {
+			devices = append(devices, di)
+		}
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Path < devices[j].Path
+	})
+
+	return devices, nil
+}
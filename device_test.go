@@ -0,0 +1,107 @@
+package blink
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDevice is a Device which records the colors it was set to.
+type fakeDevice struct {
+	colors    []Color
+	durations []time.Duration
+	closed    bool
+}
+
+func (d *fakeDevice) Set(c Color) error {
+	return d.Fade(c, 0)
+}
+
+func (d *fakeDevice) Fade(c Color, duration time.Duration) error {
+	d.colors = append(d.colors, c)
+	d.durations = append(d.durations, duration)
+	return nil
+}
+
+func (d *fakeDevice) Read() (Color, error) {
+	if len(d.colors) == 0 {
+		return Color{}, nil
+	}
+	return d.colors[len(d.colors)-1], nil
+}
+
+func (d *fakeDevice) Close() error {
+	d.closed = true
+	return nil
+}
+
+func TestDeviceInfoString(t *testing.T) {
+	di := DeviceInfo{
+		Path:      "27b8:01ed:02.04",
+		VendorID:  VendorNumber,
+		ProductID: ProductNumber,
+		Serial:    "2000ABCD",
+		Product:   "blink(1) mk2",
+	}
+
+	assert.Equal(t, `27b8:01ed:02.04 serial=2000ABCD product="blink(1) mk2"`, di.String())
+}
+
+func TestSequencePlaysOnAnyDevice(t *testing.T) {
+	d := &fakeDevice{}
+	s := NewSequence().
+		Set(Red, 0).
+		Fade(Green, 10*time.Millisecond).
+		FadeFunc(func() Color { return Blue }, 0).
+		Off()
+
+	require.NoError(t, s.Play(d))
+	assert.Equal(t, []Color{Red, Green, Blue, {}}, d.colors)
+	assert.Equal(t, []time.Duration{0, 10 * time.Millisecond, 0, 0}, d.durations)
+
+	err := NewSequence().SetLED(1, Red, 0).Play(d)
+	assert.EqualError(t, err, "can not address individual LEDs of *blink.fakeDevice")
+}
+
+func TestSequencePlayContextOnAnyDevice(t *testing.T) {
+	s, _ := NewSequence().Set(Red, time.Hour).Loop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	err := s.PlayContext(ctx, &fakeDevice{})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestSequencePlayRejectsNilDevice(t *testing.T) {
+	s := NewSequence().Set(Red, 0)
+
+	var led *LED
+	assert.Error(t, s.Play(led))
+	assert.Error(t, s.Play(nil))
+
+	var g *Group
+	assert.Error(t, s.Play(g))
+}
+
+func TestGroupChannelIsDevice(t *testing.T) {
+	a, b := newColorTransport(), newColorTransport()
+	g := NewGroup(NewLED(a), NewLED(b))
+
+	s := NewSequence().SetLED(1, Red, 0).SetLED(2, Blue, 0)
+	require.NoError(t, s.Play(g))
+	assert.Equal(t, Red, a.colors[1])
+	assert.Equal(t, Blue, a.colors[2])
+	assert.Equal(t, Red, b.colors[1])
+	assert.Equal(t, Blue, b.colors[2])
+
+	require.NoError(t, NewSequence().Set(Green, 0).Play(g.Channel(1)))
+	assert.Equal(t, Green, b.colors[1])
+	assert.Equal(t, Blue, b.colors[2])
+}
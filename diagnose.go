@@ -0,0 +1,207 @@
+package blink
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// latencySamples is the number of round trips that are used to measure the latency of a device.
+const latencySamples = 5
+
+// SelfTest sends the test report to the device and checks its response.
+func (l *LED) SelfTest() error {
+	return l.SelfTestContext(context.Background())
+}
+
+// SelfTestContext is like SelfTest but aborts when ctx is done.
+func (l *LED) SelfTestContext(ctx context.Context) error {
+	buf, err := l.read(ctx, &testCommand{})
+	if err != nil {
+		return err
+	}
+
+	if buf[2] != 0x55 || buf[3] != 0xAA {
+		return fmt.Errorf("unexpected response to self test: % x", buf)
+	}
+
+	return nil
+}
+
+// A Check is the result of a single check of a diagnostics run.
+type Check struct {
+	Name     string
+	Err      error // nil if the check passed
+	Duration time.Duration
+}
+
+// A Diagnosis is the result of a diagnostics run on a device.
+type Diagnosis struct {
+	Device       DeviceInfo
+	Capabilities Capabilities  // only set if the firmware version could be read
+	Latency      time.Duration // the average round trip time of a transfer
+	Checks       []Check
+}
+
+// Diagnose opens a device using the given options, runs all diagnostic checks
+// on it and closes it again. Failures are recorded in the returned Diagnosis.
+// Example:
+//     d := blink.Diagnose(blink.WithSerial("2000ABCD"))
+//     if err := d.Err(); err != nil {
+//         log.Println(d)
+//     }
+func Diagnose(opts ...Option) *Diagnosis {
+	return DiagnoseContext(context.Background(), opts...)
+}
+
+// DiagnoseContext is like Diagnose but aborts the checks when ctx is done.
+// The device is closed in any case.
+func DiagnoseContext(ctx context.Context, opts ...Option) *Diagnosis {
+	d := new(Diagnosis)
+
+	var led *LED
+	err := d.check("open", func() (err error) {
+		led, err = New(opts...)
+		return err
+	})
+	if err != nil {
+		return d
+	}
+
+	d.Device = led.Info()
+	led.diagnose(ctx, d)
+	d.check("close", led.Close)
+
+	return d
+}
+
+// Diagnose runs all diagnostic checks on the LED. In contrast to the Diagnose
+// function the LED is neither opened nor closed.
+func (l *LED) Diagnose() *Diagnosis {
+	return l.DiagnoseContext(context.Background())
+}
+
+// DiagnoseContext is like Diagnose but aborts the checks when ctx is done.
+func (l *LED) DiagnoseContext(ctx context.Context) *Diagnosis {
+	d := &Diagnosis{Device: l.Info()}
+	l.diagnose(ctx, d)
+	return d
+}
+
+func (l *LED) diagnose(ctx context.Context, d *Diagnosis) {
+	err := d.check("firmware version", func() error {
+		caps, err := l.CapabilitiesContext(ctx)
+		if err == nil {
+			d.Capabilities = caps
+		}
+		return err
+	})
+	if err != nil {
+		// without the version we neither know if the device responds at all
+		// nor which of the following checks it supports
+		return
+	}
+
+	d.check("self test", func() error {
+		return l.SelfTestContext(ctx)
+	})
+
+	d.check("latency", func() error {
+		start := time.Now()
+		for i := 0; i < latencySamples; i++ {
+			if _, err := l.VersionContext(ctx); err != nil {
+				return err
+			}
+		}
+
+		d.Latency = time.Since(start) / latencySamples
+		return nil
+	})
+
+	if d.Capabilities.Readback && d.Capabilities.PerLEDAddressing {
+		colors := []Color{Red, Green, Blue}
+		for n := 1; n <= d.Capabilities.LEDs; n++ {
+			c := colors[(n-1)%len(colors)]
+			d.check(fmt.Sprintf("led %d", n), func() error {
+				return l.verifyColor(ctx, byte(n), c)
+			})
+		}
+	}
+}
+
+// verifyColor sets the LED with index n to c and checks that the device reports c as its color.
+// The previous color of the LED is restored afterwards.
+func (l *LED) verifyColor(ctx context.Context, n byte, c Color) (err error) {
+	buf, err := l.read(ctx, &readRGBCommand{n: n})
+	if err != nil {
+		return err
+	}
+
+	previous := Color{R: buf[2], G: buf[3], B: buf[4]}
+	defer func() {
+		_, restoreErr := l.write(ctx, &fadeRGBCommand{Color: previous, n: n})
+		if err == nil {
+			err = restoreErr
+		}
+	}()
+
+	if _, err := l.write(ctx, &fadeRGBCommand{Color: c, n: n}); err != nil {
+		return err
+	}
+
+	buf, err = l.read(ctx, &readRGBCommand{n: n})
+	if err != nil {
+		return err
+	}
+
+	if actual := (Color{R: buf[2], G: buf[3], B: buf[4]}); actual != c {
+		return fmt.Errorf("device reported color %v instead of %v", actual, c)
+	}
+
+	return nil
+}
+
+// check runs the given function and records its result.
+func (d *Diagnosis) check(name string, f func() error) error {
+	start := time.Now()
+	err := f()
+	d.Checks = append(d.Checks, Check{Name: name, Err: err, Duration: time.Since(start)})
+	return err
+}
+
+// Err returns nil if all checks passed or a *multierror.Error of all failed checks.
+func (d *Diagnosis) Err() error {
+	var result *multierror.Error
+	for _, c := range d.Checks {
+		if c.Err != nil {
+			result = multierror.Append(result, fmt.Errorf("%s: %w", c.Name, c.Err))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+// String implements fmt.Stringer by formatting the diagnosis as one line per check.
+func (d *Diagnosis) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "device: %s\n", d.Device)
+	if d.Capabilities.Firmware != 0 {
+		fmt.Fprintf(&b, "firmware: v%d.%02d (%s)\n", d.Capabilities.Firmware/100, d.Capabilities.Firmware%100, d.Capabilities.Generation)
+	}
+	if d.Latency != 0 {
+		fmt.Fprintf(&b, "latency: %s\n", d.Latency)
+	}
+
+	for _, c := range d.Checks {
+		result := "ok"
+		if c.Err != nil {
+			result = "FAILED: " + c.Err.Error()
+		}
+		fmt.Fprintf(&b, "%s: %s (%s)\n", c.Name, result, c.Duration)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package blink
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// colorTransport fakes a mk2 device which remembers the color of each LED.
+type colorTransport struct {
+	fakeTransport
+	colors map[byte]Color
+	broken byte // the LED which always reports black
+}
+
+func newColorTransport() *colorTransport {
+	return &colorTransport{
+		fakeTransport: fakeTransport{responses: map[byte][]byte{
+			'v': mk2Version,
+			'!': {0x01, '!', 0x55, 0xAA, 0x00, 0x00, 0x00, 0x00},
+		}},
+		colors: map[byte]Color{},
+	}
+}
+
+func (t *colorTransport) WriteReport(ctx context.Context, report []byte) error {
+	if report[1] == 'c' && report[7] != t.broken {
+		t.colors[report[7]] = Color{report[2], report[3], report[4]}
+	}
+
+	return t.fakeTransport.WriteReport(ctx, report)
+}
+
+func (t *colorTransport) ReadReport(ctx context.Context, report []byte) error {
+	last := t.written[len(t.written)-1]
+	if last[1] == 'r' {
+		c := t.colors[last[7]]
+		copy(report, []byte{0x01, 'r', c.R, c.G, c.B, 0x00, 0x00, last[7]})
+		return nil
+	}
+
+	return t.fakeTransport.ReadReport(ctx, report)
+}
+
+func TestSelfTest(t *testing.T) {
+	tr := newColorTransport()
+	led := NewLED(tr)
+	assert.NoError(t, led.SelfTest())
+
+	tr.responses['!'] = []byte{0x01, '!', 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	assert.Error(t, led.SelfTest())
+}
+
+func TestDiagnose(t *testing.T) {
+	tr := newColorTransport()
+	tr.colors[2] = Color{1, 2, 3}
+
+	d := NewLED(tr).Diagnose()
+	require.NoError(t, d.Err())
+	assert.Equal(t, MK2, d.Capabilities.Generation)
+	assert.NotZero(t, d.Latency)
+
+	var names []string
+	for _, c := range d.Checks {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"firmware version", "self test", "latency", "led 1", "led 2"}, names)
+
+	// the previous colors are restored after the check
+	assert.Equal(t, Color{}, tr.colors[1])
+	assert.Equal(t, Color{1, 2, 3}, tr.colors[2])
+}
+
+func TestDiagnoseReportsFailedChecks(t *testing.T) {
+	tr := newColorTransport()
+	tr.broken = 2
+
+	d := NewLED(tr).Diagnose()
+	require.Error(t, d.Err())
+	assert.Contains(t, d.Err().Error(), "led 2")
+	assert.NoError(t, d.Checks[3].Err)
+	assert.Error(t, d.Checks[4].Err)
+	assert.True(t, strings.Contains(d.String(), "led 2: FAILED"))
+}
+
+func TestDiagnoseMK1(t *testing.T) {
+	tr := newColorTransport()
+	tr.responses['v'] = mk1Version
+
+	d := NewLED(tr).Diagnose()
+	require.NoError(t, d.Err())
+	assert.Len(t, d.Checks, 3)
+	assert.Contains(t, d.String(), "firmware: v1.05 (mk1)")
+}
@@ -0,0 +1,191 @@
+package blink
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// EEPROMSize is the number of bytes of the EEPROM of mk1 devices that can be addressed.
+const EEPROMSize = 256
+
+// ErrEEPROMLocked is returned when writing to the EEPROM before it has been unlocked via LED.UnlockEEPROM.
+var ErrEEPROMLocked = errors.New("EEPROM is locked")
+
+// UnlockEEPROM allows writing to the EEPROM of the device.
+// The EEPROM of mk1 devices stores their startup pattern and settings so
+// writing to it by accident can leave the device in an unusable state.
+func (l *LED) UnlockEEPROM() {
+	l.mu.Lock()
+	l.eepromUnlocked = true
+	l.mu.Unlock()
+}
+
+// LockEEPROM prevents further writes to the EEPROM of the device.
+func (l *LED) LockEEPROM() {
+	l.mu.Lock()
+	l.eepromUnlocked = false
+	l.mu.Unlock()
+}
+
+// ReadEEPROM reads the byte at the given address of the EEPROM.
+// Accessing the EEPROM is supported by mk1 devices only.
+func (l *LED) ReadEEPROM(addr int) (byte, error) {
+	return l.ReadEEPROMContext(context.Background(), addr)
+}
+
+// ReadEEPROMContext is like ReadEEPROM but aborts when ctx is done.
+func (l *LED) ReadEEPROMContext(ctx context.Context, addr int) (byte, error) {
+	if err := l.requireEEPROM(ctx, "reading the EEPROM", addr); err != nil {
+		return 0, err
+	}
+
+	buf, err := l.read(ctx, &readEEPROMCommand{addr: byte(addr)})
+	if err != nil {
+		return 0, err
+	}
+
+	return buf[3], nil
+}
+
+// WriteEEPROM writes the given byte to the given address of the EEPROM.
+// ErrEEPROMLocked is returned unless the EEPROM has been unlocked via UnlockEEPROM.
+// Accessing the EEPROM is supported by mk1 devices only.
+func (l *LED) WriteEEPROM(addr int, val byte) error {
+	return l.WriteEEPROMContext(context.Background(), addr, val)
+}
+
+// WriteEEPROMContext is like WriteEEPROM but aborts when ctx is done.
+func (l *LED) WriteEEPROMContext(ctx context.Context, addr int, val byte) error {
+	if err := l.requireEEPROM(ctx, "writing the EEPROM", addr); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	unlocked := l.eepromUnlocked
+	l.mu.Unlock()
+
+	if !unlocked {
+		return ErrEEPROMLocked
+	}
+
+	_, err := l.write(ctx, &writeEEPROMCommand{addr: byte(addr), val: val})
+	return err
+}
+
+func (l *LED) requireEEPROM(ctx context.Context, op string, addr int) error {
+	if err := l.require(ctx, op, func(c Capabilities) bool { return c.Generation == MK1 }); err != nil {
+		return err
+	}
+
+	if addr < 0 || addr >= EEPROMSize {
+		return fmt.Errorf("EEPROM address %d is out of range [0, %d]", addr, EEPROMSize-1)
+	}
+
+	return nil
+}
+
+// DumpEEPROM reads the whole EEPROM and writes its contents to w.
+func (l *LED) DumpEEPROM(w io.Writer) error {
+	return l.DumpEEPROMContext(context.Background(), w)
+}
+
+// DumpEEPROMContext is like DumpEEPROM but aborts when ctx is done.
+func (l *LED) DumpEEPROMContext(ctx context.Context, w io.Writer) error {
+	data := make([]byte, EEPROMSize)
+	for addr := range data {
+		var err error
+		data[addr], err = l.ReadEEPROMContext(ctx, addr)
+		if err != nil {
+			return fmt.Errorf("could not read EEPROM address %d: %w", addr, err)
+		}
+	}
+
+	_, err := w.Write(data)
+	return err
+}
+
+// RestoreEEPROM writes a dump created by DumpEEPROM back to the EEPROM.
+// Only bytes which differ from the current contents of the EEPROM are written.
+// ErrEEPROMLocked is returned unless the EEPROM has been unlocked via UnlockEEPROM.
+func (l *LED) RestoreEEPROM(r io.Reader) error {
+	return l.RestoreEEPROMContext(context.Background(), r)
+}
+
+// RestoreEEPROMContext is like RestoreEEPROM but aborts when ctx is done.
+func (l *LED) RestoreEEPROMContext(ctx context.Context, r io.Reader) error {
+	data := make([]byte, EEPROMSize+1)
+	n, err := io.ReadFull(r, data)
+	switch {
+	case err == io.ErrUnexpectedEOF && n == EEPROMSize:
+		data = data[:n]
+	case err == nil:
+		return fmt.Errorf("EEPROM dump is larger than %d bytes", EEPROMSize)
+	case err == io.ErrUnexpectedEOF || err == io.EOF:
+		return fmt.Errorf("EEPROM dump has only %d of %d bytes", n, EEPROMSize)
+	default:
+		return fmt.Errorf("could not read EEPROM dump: %w", err)
+	}
+
+	for addr, val := range data {
+		current, err := l.ReadEEPROMContext(ctx, addr)
+		if err != nil {
+			return fmt.Errorf("could not read EEPROM address %d: %w", addr, err)
+		}
+
+		if current == val {
+			continue
+		}
+
+		if err := l.WriteEEPROMContext(ctx, addr, val); err != nil {
+			return fmt.Errorf("could not write EEPROM address %d: %w", addr, err)
+		}
+	}
+
+	return nil
+}
+
+// DumpEEPROMFile writes the contents of the EEPROM to the file at path.
+// Existing files are overwritten.
+func (l *LED) DumpEEPROMFile(path string) error {
+	return l.DumpEEPROMFileContext(context.Background(), path)
+}
+
+// DumpEEPROMFileContext is like DumpEEPROMFile but aborts when ctx is done.
+func (l *LED) DumpEEPROMFileContext(ctx context.Context, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	err = l.DumpEEPROMContext(ctx, w)
+	if err == nil {
+		err = w.Flush()
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// RestoreEEPROMFile restores the EEPROM from a file that was created by DumpEEPROMFile.
+func (l *LED) RestoreEEPROMFile(path string) error {
+	return l.RestoreEEPROMFileContext(context.Background(), path)
+}
+
+// RestoreEEPROMFileContext is like RestoreEEPROMFile but aborts when ctx is done.
+func (l *LED) RestoreEEPROMFileContext(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return l.RestoreEEPROMContext(ctx, f)
+}
@@ -0,0 +1,120 @@
+package blink
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eepromTransport fakes the EEPROM of a mk1 device.
+type eepromTransport struct {
+	fakeTransport
+	mem [EEPROMSize]byte
+}
+
+func newEEPROMTransport() *eepromTransport {
+	return &eepromTransport{fakeTransport: fakeTransport{responses: map[byte][]byte{'v': mk1Version}}}
+}
+
+func (t *eepromTransport) WriteReport(ctx context.Context, report []byte) error {
+	if report[1] == 'E' {
+		t.mem[report[2]] = report[3]
+	}
+
+	return t.fakeTransport.WriteReport(ctx, report)
+}
+
+func (t *eepromTransport) ReadReport(ctx context.Context, report []byte) error {
+	last := t.written[len(t.written)-1]
+	if last[1] == 'e' {
+		copy(report, last)
+		report[3] = t.mem[last[2]]
+		return nil
+	}
+
+	return t.fakeTransport.ReadReport(ctx, report)
+}
+
+func TestEEPROM(t *testing.T) {
+	tr := newEEPROMTransport()
+	tr.mem[3] = 0x42
+	led := NewLED(tr)
+
+	val, err := led.ReadEEPROM(3)
+	require.NoError(t, err)
+	assert.Equal(t, byte(0x42), val)
+
+	assert.Equal(t, ErrEEPROMLocked, led.WriteEEPROM(3, 0x01))
+	assert.Equal(t, byte(0x42), tr.mem[3])
+
+	led.UnlockEEPROM()
+	require.NoError(t, led.WriteEEPROM(3, 0x01))
+	assert.Equal(t, byte(0x01), tr.mem[3])
+
+	led.LockEEPROM()
+	assert.Equal(t, ErrEEPROMLocked, led.WriteEEPROM(3, 0x02))
+
+	_, err = led.ReadEEPROM(EEPROMSize)
+	assert.Error(t, err)
+	_, err = led.ReadEEPROM(-1)
+	assert.Error(t, err)
+}
+
+func TestEEPROMIsMK1Only(t *testing.T) {
+	led := NewLED(&fakeTransport{responses: map[byte][]byte{'v': mk2Version}})
+	led.UnlockEEPROM()
+
+	_, err := led.ReadEEPROM(0)
+	assert.True(t, errors.Is(err, ErrUnsupported))
+	assert.True(t, errors.Is(led.WriteEEPROM(0, 1), ErrUnsupported))
+}
+
+func TestDumpAndRestoreEEPROM(t *testing.T) {
+	tr := newEEPROMTransport()
+	for i := range tr.mem {
+		tr.mem[i] = byte(i)
+	}
+	led := NewLED(tr)
+
+	var dump bytes.Buffer
+	require.NoError(t, led.DumpEEPROM(&dump))
+	assert.Equal(t, tr.mem[:], dump.Bytes())
+
+	tr.mem[10], tr.mem[20] = 0, 0
+	assert.True(t, errors.Is(led.RestoreEEPROM(bytes.NewReader(dump.Bytes())), ErrEEPROMLocked))
+
+	led.UnlockEEPROM()
+	tr.written = nil
+	require.NoError(t, led.RestoreEEPROM(bytes.NewReader(dump.Bytes())))
+	assert.Equal(t, dump.Bytes(), tr.mem[:])
+
+	var writes int
+	for _, report := range tr.written {
+		if report[1] == 'E' {
+			writes++
+		}
+	}
+	assert.Equal(t, 2, writes, "only changed bytes should be written")
+
+	assert.Error(t, led.RestoreEEPROM(bytes.NewReader(make([]byte, EEPROMSize-1))))
+	assert.Error(t, led.RestoreEEPROM(bytes.NewReader(make([]byte, EEPROMSize+1))))
+}
+
+func TestDumpAndRestoreEEPROMFile(t *testing.T) {
+	tr := newEEPROMTransport()
+	tr.mem[7] = 0x77
+	led := NewLED(tr)
+	led.UnlockEEPROM()
+
+	path := filepath.Join(t.TempDir(), "eeprom.bin")
+	require.NoError(t, led.DumpEEPROMFile(path))
+
+	tr.mem[7] = 0
+	require.NoError(t, led.RestoreEEPROMFile(path))
+	assert.Equal(t, byte(0x77), tr.mem[7])
+}
@@ -0,0 +1,44 @@
+package blink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// The following errors classify why a transfer to a device failed.
+// Use errors.Is to check if an error returned by this package belongs to one of these classes.
+// Example:
+//     if errors.Is(err, blink.ErrPermissionDenied) {
+//         fmt.Println("Please add the udev rule from the README")
+//     }
+var (
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrDisconnected     = errors.New("device has been disconnected")
+	ErrBusy             = errors.New("device or resource busy")
+	ErrTimeout          = errors.New("timeout")
+	ErrPipe             = errors.New("pipe error") // the device stalled the transfer
+)
+
+// A DeviceError is returned if opening or communicating with a device failed.
+// It contains the raw error code of the backend which is a libusb error code
+// for the libusb backend and an errno for the hidraw backend.
+type DeviceError struct {
+	Path string // the path of the device (see DeviceInfo.Path)
+	Code int    // the raw error code of the backend or zero if there is none
+	Err  error  // the underlying error
+}
+
+func (e *DeviceError) Error() string {
+	return fmt.Sprintf("blink1 device %s: %s", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *DeviceError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes a DeviceError which was caused by an exceeded context deadline match ErrTimeout.
+func (e *DeviceError) Is(target error) bool {
+	return target == ErrTimeout && errors.Is(e.Err, context.DeadlineExceeded)
+}
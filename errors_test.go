@@ -0,0 +1,30 @@
+package blink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceError(t *testing.T) {
+	cause := errors.New("something went wrong")
+	err := fmt.Errorf("could not set color: %w", &DeviceError{Path: "27b8:01ed:02", Code: -1, Err: cause})
+
+	assert.EqualError(t, err, "could not set color: blink1 device 27b8:01ed:02: something went wrong")
+	assert.True(t, errors.Is(err, cause))
+
+	var devErr *DeviceError
+	assert.True(t, errors.As(err, &devErr))
+	assert.Equal(t, "27b8:01ed:02", devErr.Path)
+	assert.Equal(t, -1, devErr.Code)
+}
+
+func TestDeviceErrorWithExceededDeadlineIsTimeout(t *testing.T) {
+	err := &DeviceError{Path: "27b8:01ed:02", Err: context.DeadlineExceeded}
+	assert.True(t, errors.Is(err, ErrTimeout))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.False(t, errors.Is(err, ErrDisconnected))
+}
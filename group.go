@@ -0,0 +1,190 @@
+package blink
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// ErrorPolicy determines when an operation on a Group returns an error.
+type ErrorPolicy int
+
+const (
+	// FailOnAnyError lets an operation fail if it failed for any member of the group.
+	FailOnAnyError ErrorPolicy = iota
+
+	// FailOnAllErrors lets an operation fail only if it failed for all members
+	// of the group. This is useful if the group should keep working even if
+	// some of its devices have been unplugged.
+	FailOnAllErrors
+)
+
+// A Group controls multiple LEDs in lockstep, e.g. to show the same status on
+// several blink(1) devices at the same time. Each command is sent to all members
+// concurrently to minimize the time between the updates of the individual devices.
+//
+// Errors of individual members are combined into a *multierror.Error.
+// The OnError function can be used to get notified about each individual error,
+// even if the Policy does not let the operation fail.
+type Group struct {
+	LEDs    []*LED
+	Policy  ErrorPolicy
+	OnError func(led *LED, err error)
+}
+
+// NewGroup creates a new Group of the given LEDs which uses the FailOnAnyError policy.
+func NewGroup(leds ...*LED) *Group {
+	return &Group{LEDs: leds}
+}
+
+// Set lights up all LEDs with the specified color immediately.
+func (g *Group) Set(c Color) error {
+	return g.SetContext(context.Background(), c)
+}
+
+// SetContext is like Set but aborts when ctx is done.
+func (g *Group) SetContext(ctx context.Context, c Color) error {
+	return g.each(func(led *LED) error {
+		return led.SetContext(ctx, c)
+	})
+}
+
+// Fade lets all LEDs fade to the specified color over the given duration.
+func (g *Group) Fade(c Color, d time.Duration) error {
+	return g.FadeContext(context.Background(), c, d)
+}
+
+// FadeContext is like Fade but aborts when ctx is done.
+func (g *Group) FadeContext(ctx context.Context, c Color, d time.Duration) error {
+	return g.each(func(led *LED) error {
+		return led.FadeContext(ctx, c, d)
+	})
+}
+
+// Read returns the current color of the first member whose color could be read.
+// Use ReadAll to get the colors of all members.
+func (g *Group) Read() (Color, error) {
+	return g.ReadContext(context.Background())
+}
+
+// ReadContext is like Read but aborts when ctx is done.
+func (g *Group) ReadContext(ctx context.Context) (Color, error) {
+	colors, errs := g.readAll(ctx)
+	err := g.result(errs)
+	for i, c := range colors {
+		if errs[i] == nil {
+			return c, err
+		}
+	}
+
+	return Color{}, err
+}
+
+// ReadAll returns the current colors of all members in the same order as
+// g.LEDs. The color of a member that could not be read is zero.
+func (g *Group) ReadAll() ([]Color, error) {
+	return g.ReadAllContext(context.Background())
+}
+
+// ReadAllContext is like ReadAll but aborts when ctx is done.
+func (g *Group) ReadAllContext(ctx context.Context) ([]Color, error) {
+	colors, errs := g.readAll(ctx)
+	return colors, g.result(errs)
+}
+
+func (g *Group) readAll(ctx context.Context) ([]Color, []error) {
+	colors := make([]Color, len(g.LEDs))
+	errs := g.run(func(i int, led *LED) (err error) {
+		colors[i], err = led.ReadContext(ctx)
+		return err
+	})
+
+	return colors, errs
+}
+
+// Play plays the given sequence on all LEDs in sync.
+// It blocks until all frames have been processed.
+func (g *Group) Play(s *Sequence) error {
+	return g.PlayContext(context.Background(), s)
+}
+
+// PlayContext is like Play but stops the playback as soon as ctx is done.
+func (g *Group) PlayContext(ctx context.Context, s *Sequence) error {
+	return s.PlayContext(ctx, g)
+}
+
+// Channel returns a Group of the Channel n of each member of g.
+// The returned Group uses the same Policy and OnError function as g.
+func (g *Group) Channel(n byte) *Group {
+	leds := make([]*LED, len(g.LEDs))
+	for i, led := range g.LEDs {
+		leds[i] = led.Channel(n)
+	}
+
+	return &Group{LEDs: leds, Policy: g.Policy, OnError: g.OnError}
+}
+
+// Close closes all LEDs of the group.
+func (g *Group) Close() error {
+	var result error
+	for _, led := range g.LEDs {
+		if err := led.Close(); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	return result
+}
+
+func (g *Group) each(f func(*LED) error) error {
+	return g.result(g.run(func(_ int, led *LED) error {
+		return f(led)
+	}))
+}
+
+// run calls f concurrently for each member and returns the errors in the order of g.LEDs.
+// All goroutines are started before any of them calls f to minimize the skew between the members.
+func (g *Group) run(f func(int, *LED) error) []error {
+	errs := make([]error, len(g.LEDs))
+	start := make(chan struct{})
+	done := make(chan struct{})
+
+	for i, led := range g.LEDs {
+		go func(i int, led *LED) {
+			<-start
+			errs[i] = f(i, led)
+			done <- struct{}{}
+		}(i, led)
+	}
+
+	close(start)
+	for range g.LEDs {
+		<-done
+	}
+
+	return errs
+}
+
+// result applies the error policy to the errors of the individual members.
+func (g *Group) result(errs []error) error {
+	var result error
+	failed := 0
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		failed++
+		result = multierror.Append(result, err)
+		if g.OnError != nil {
+			g.OnError(g.LEDs[i], err)
+		}
+	}
+
+	if g.Policy == FailOnAllErrors && failed < len(errs) {
+		return nil
+	}
+
+	return result
+}
@@ -0,0 +1,82 @@
+package blink
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupSendsCommandsToAllMembers(t *testing.T) {
+	a, b := &fakeTransport{}, &fakeTransport{}
+	g := NewGroup(NewLED(a), NewLED(b))
+
+	require.NoError(t, g.Set(Red))
+	require.NoError(t, g.Fade(Blue, 100*time.Millisecond))
+
+	want := [][]byte{
+		{0x01, 'n', 0xff, 0x00, 0x00, 0x00, 0x00, 0x00},
+		{0x01, 'c', 0x00, 0x00, 0xff, 0x00, 0x0a, 0x00},
+	}
+	assert.Equal(t, want, a.written)
+	assert.Equal(t, want, b.written)
+
+	require.NoError(t, g.Close())
+	assert.True(t, a.closed)
+	assert.True(t, b.closed)
+}
+
+func TestGroupReadAll(t *testing.T) {
+	response := func(c Color) map[byte][]byte {
+		return map[byte][]byte{'v': mk2Version, 'r': {0x01, 'r', c.R, c.G, c.B, 0, 0, 0}}
+	}
+	g := NewGroup(
+		NewLED(&fakeTransport{responses: response(Red)}),
+		NewLED(&fakeTransport{responses: response(Green)}),
+	)
+
+	colors, err := g.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []Color{Red, Green}, colors)
+
+	c, err := g.Read()
+	require.NoError(t, err)
+	assert.Equal(t, Red, c)
+}
+
+func TestGroupErrorPolicy(t *testing.T) {
+	unplugged := &flakyTransport{n: 100, err: &DeviceError{Path: "27b8:01ed:02", Err: ErrDisconnected}}
+	working := &fakeTransport{}
+	g := NewGroup(NewLED(unplugged), NewLED(working))
+
+	var reported []error
+	g.OnError = func(led *LED, err error) {
+		assert.Equal(t, g.LEDs[0], led)
+		reported = append(reported, err)
+	}
+
+	err := g.Set(Red)
+	assert.True(t, errors.Is(err, ErrDisconnected))
+	assert.Len(t, reported, 1)
+
+	g.Policy = FailOnAllErrors
+	assert.NoError(t, g.Set(Green))
+	assert.Len(t, reported, 2)
+	assert.Len(t, working.written, 2)
+
+	g.LEDs = g.LEDs[:1]
+	assert.Error(t, g.Set(Blue))
+}
+
+func TestGroupPlaysSequenceOnAllMembers(t *testing.T) {
+	a, b := &fakeTransport{}, &fakeTransport{}
+	g := NewGroup(NewLED(a), NewLED(b))
+
+	s := NewSequence().Set(Red, 0).Fade(Green, 10*time.Millisecond).Off()
+	require.NoError(t, g.Play(s))
+
+	assert.Len(t, a.written, 3)
+	assert.Equal(t, a.written, b.written)
+}
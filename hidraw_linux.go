@@ -0,0 +1,334 @@
+//go:build linux && !libusb
+// +build linux,!libusb
+
+package blink
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// sysfsHidrawPath is the sysfs directory which contains one entry for each hidraw device.
+var sysfsHidrawPath = "/sys/class/hidraw"
+
+const (
+	iocRead  = 2
+	iocWrite = 1
+
+	hidiocSFeature = 0x06 // HIDIOCSFEATURE as defined in linux/hidraw.h
+	hidiocGFeature = 0x07 // HIDIOCGFEATURE as defined in linux/hidraw.h
+)
+
+type usbDevice struct {
+	file *os.File
+	info DeviceInfo
+	busy chan struct{} // held while an ioctl is in progress
+}
+
+func usbDevices() ([]DeviceInfo, error) {
+	entries, err := os.ReadDir(sysfsHidrawPath)
+	if os.IsNotExist(err) {
+		return nil, nil // the hidraw module is not loaded so there can't be any devices
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result []DeviceInfo
+	for _, e := range entries {
+		di, err := readDeviceInfo(e.Name())
+		if err != nil {
+			continue
+		}
+		result = append(result, di)
+	}
+
+	return result, nil
+}
+
+func (di DeviceInfo) open() (*usbDevice, error) {
+	entries, err := os.ReadDir(sysfsHidrawPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open usb device with path %q: could not list hidraw devices: %s", di.Path, err)
+	}
+
+	for _, e := range entries {
+		candidate, err := readDeviceInfo(e.Name())
+		if err != nil {
+			continue
+		}
+
+		if di.Path == candidate.Path {
+			f, err := os.OpenFile(filepath.Join("/dev", e.Name()), os.O_RDWR, 0)
+			if err != nil {
+				var errno syscall.Errno
+				if errors.As(err, &errno) {
+					return nil, di.error(errno)
+				}
+				return nil, err
+			}
+
+			return &usbDevice{file: f, info: di, busy: make(chan struct{}, 1)}, nil
+		}
+	}
+
+	return nil, errors.New("couldn't open hidraw device")
+}
+
+// readDeviceInfo reads the information about the hidraw device with the given
+// name (e.g. "hidraw0") from sysfs.
+func readDeviceInfo(name string) (di DeviceInfo, err error) {
+	dir := filepath.Join(sysfsHidrawPath, name, "device")
+	uevent, err := os.ReadFile(filepath.Join(dir, "uevent"))
+	if err != nil {
+		return di, err
+	}
+
+	vendorID, productID, err := parseHIDID(uevent)
+	if err != nil {
+		return di, err
+	}
+
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		return di, err
+	}
+
+	numbers, err := getPortNumbers(dir)
+	if err != nil {
+		// devices which are not connected via USB, e.g. virtual uhid devices,
+		// are identified by the information the HID driver got from them
+		return virtualDeviceInfo(uevent, vendorID, productID, name), nil
+	}
+
+	// the parent of the USB interface is the USB device itself
+	usbDir := filepath.Dir(filepath.Dir(dir))
+
+	return DeviceInfo{
+		Path:      fmt.Sprintf("%.4x:%.4x:%s", vendorID, productID, numbers),
+		VendorID:  vendorID,
+		ProductID: productID,
+		Serial:    readSysfsString(filepath.Join(usbDir, "serial")),
+		Product:   readSysfsString(filepath.Join(usbDir, "product")),
+	}, nil
+}
+
+// virtualDeviceInfo returns the DeviceInfo of a HID device which is not a USB device.
+// Its path is built from the physical location that was reported by the device
+// or from the name of the hidraw device if there is none.
+func virtualDeviceInfo(uevent []byte, vendorID, productID uint16, name string) DeviceInfo {
+	location := ueventValue(uevent, "HID_PHYS")
+	if location == "" {
+		location = name
+	}
+
+	return DeviceInfo{
+		Path:      fmt.Sprintf("%.4x:%.4x:%s", vendorID, productID, location),
+		VendorID:  vendorID,
+		ProductID: productID,
+		Serial:    ueventValue(uevent, "HID_UNIQ"),
+		Product:   ueventValue(uevent, "HID_NAME"),
+	}
+}
+
+// ueventValue returns the value of the given key of a uevent file or an empty string.
+func ueventValue(uevent []byte, key string) string {
+	s := bufio.NewScanner(bytes.NewReader(uevent))
+	for s.Scan() {
+		if v := strings.TrimPrefix(s.Text(), key+"="); v != s.Text() {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// readSysfsString returns the trimmed content of the given sysfs attribute
+// or an empty string if it can not be read.
+func readSysfsString(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(b))
+}
+
+// parseHIDID extracts the vendor and product ID from the HID_ID line of a
+// uevent file. The line has the form "HID_ID=<bus>:<vendor>:<product>".
+func parseHIDID(uevent []byte) (vendorID, productID uint16, err error) {
+	s := bufio.NewScanner(bytes.NewReader(uevent))
+	for s.Scan() {
+		line := s.Text()
+		if !strings.HasPrefix(line, "HID_ID=") {
+			continue
+		}
+
+		var bus, vendor, product uint32
+		_, err = fmt.Sscanf(line, "HID_ID=%x:%x:%x", &bus, &vendor, &product)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid HID_ID %q: %s", line, err)
+		}
+
+		return uint16(vendor), uint16(product), nil
+	}
+
+	return 0, 0, errors.New("uevent does not contain a HID_ID")
+}
+
+// getPortNumbers returns the USB port numbers of the HID device at the given
+// sysfs path in the same format as the libusb backend (e.g. "02.04").
+// The parent directory of a USB HID device is its USB interface which is named
+// "<bus>-<port>[.<port>]*:<config>.<interface>".
+func getPortNumbers(dir string) (string, error) {
+	iface := filepath.Base(filepath.Dir(dir))
+	i := strings.IndexByte(iface, '-')
+	j := strings.IndexByte(iface, ':')
+	if i < 0 || j < i {
+		return "", fmt.Errorf("%q is not a USB device", dir)
+	}
+
+	ports := strings.Split(iface[i+1:j], ".")
+	for i, p := range ports {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return "", fmt.Errorf("invalid USB port number %q: %s", p, err)
+		}
+		ports[i] = fmt.Sprintf("%.2x", n)
+	}
+
+	return strings.Join(ports, "."), nil
+}
+
+// WriteReport implements Transport by sending the report via the HIDIOCSFEATURE ioctl.
+func (d *usbDevice) WriteReport(ctx context.Context, report []byte) error {
+	return d.ioctl(ctx, hidiocSFeature, report)
+}
+
+// ReadReport implements Transport by filling report via the HIDIOCGFEATURE ioctl.
+func (d *usbDevice) ReadReport(ctx context.Context, report []byte) error {
+	return d.ioctl(ctx, hidiocGFeature, report)
+}
+
+// ioctl performs the given feature report ioctl.
+// Since ioctls can not be cancelled, the ioctl is executed in its own goroutine
+// so ioctl can return as soon as ctx is done. Any following ioctl waits until
+// the abandoned one has finished. The ioctl is issued via the raw connection
+// of the file so the file descriptor is not released by Close while an
+// abandoned ioctl is still using it.
+func (d *usbDevice) ioctl(ctx context.Context, nr uintptr, report []byte) error {
+	if d.file == nil {
+		return errors.New("hidraw device has not been opened")
+	}
+
+	if len(report) == 0 {
+		return errors.New("can not transfer empty report")
+	}
+
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+
+	select {
+	case d.busy <- struct{}{}:
+	case <-ctx.Done():
+		return &DeviceError{Path: d.info.Path, Err: ctx.Err()}
+	}
+
+	rc, err := d.file.SyscallConn()
+	if err != nil {
+		<-d.busy
+		return &DeviceError{Path: d.info.Path, Err: err}
+	}
+
+	buf := append([]byte(nil), report...) // the goroutine might outlive this call
+	done := make(chan error, 1)
+	go func() {
+		defer func() { <-d.busy }()
+
+		var errno syscall.Errno
+		err := rc.Control(func(fd uintptr) {
+			_, _, errno = syscall.Syscall(syscall.SYS_IOCTL,
+				fd,
+				ioc(iocRead|iocWrite, 'H', nr, uintptr(len(buf))),
+				uintptr(unsafe.Pointer(&buf[0])),
+			)
+		})
+		switch {
+		case err != nil:
+			done <- &DeviceError{Path: d.info.Path, Err: err}
+		case errno != 0:
+			done <- d.info.error(errno)
+		default:
+			done <- nil
+		}
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			copy(report, buf)
+		}
+		return err
+	case <-ctx.Done():
+		return &DeviceError{Path: d.info.Path, Err: ctx.Err()}
+	}
+}
+
+// ioc encodes an ioctl request number like the _IOC macro in linux/ioctl.h.
+func ioc(dir, typ, nr, size uintptr) uintptr {
+	return dir<<30 | size<<16 | typ<<8 | nr
+}
+
+// Close implements Transport by closing the hidraw device node.
+// It is safe to call Close multiple times.
+func (d *usbDevice) Close() error {
+	if d == nil || d.file == nil {
+		return nil
+	}
+
+	err := d.file.Close()
+	d.file = nil
+	return err
+}
+
+type hidrawError struct{ errno syscall.Errno }
+
+func (e hidrawError) Error() string {
+	return fmt.Sprintf("hidraw: %s", e.errno)
+}
+
+func (e hidrawError) Unwrap() error {
+	return e.errno
+}
+
+// Is maps the errno to the error classes of this package.
+func (e hidrawError) Is(target error) bool {
+	switch e.errno {
+	case syscall.EACCES, syscall.EPERM:
+		return target == ErrPermissionDenied
+	case syscall.ENODEV, syscall.ENXIO, syscall.ENOENT:
+		return target == ErrDisconnected
+	case syscall.EBUSY:
+		return target == ErrBusy
+	case syscall.ETIMEDOUT:
+		return target == ErrTimeout
+	case syscall.EPIPE:
+		return target == ErrPipe
+	default:
+		return false
+	}
+}
+
+// error wraps the given errno into a DeviceError.
+func (di DeviceInfo) error(errno syscall.Errno) error {
+	return &DeviceError{Path: di.Path, Code: int(errno), Err: hidrawError{errno}}
+}
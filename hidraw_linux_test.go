@@ -0,0 +1,126 @@
+//go:build linux && !libusb
+// +build linux,!libusb
+
+package blink
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIoctlNumbers(t *testing.T) {
+	assert.Equal(t, uintptr(0xC0084806), ioc(iocRead|iocWrite, 'H', hidiocSFeature, 8))
+	assert.Equal(t, uintptr(0xC0084807), ioc(iocRead|iocWrite, 'H', hidiocGFeature, 8))
+}
+
+func TestParseHIDID(t *testing.T) {
+	uevent := []byte("DRIVER=hid-generic\nHID_ID=0003:000027B8:000001ED\nHID_NAME=ThingM blink(1) mk2\n")
+	vendor, product, err := parseHIDID(uevent)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(VendorNumber), vendor)
+	assert.Equal(t, uint16(ProductNumber), product)
+
+	_, _, err = parseHIDID([]byte("DRIVER=hid-generic\n"))
+	assert.Error(t, err)
+}
+
+func TestList(t *testing.T) {
+	root := t.TempDir()
+	usbDir := filepath.Join(root, "devices", "usb1", "1-2.4")
+	dev := filepath.Join(usbDir, "1-2.4:1.0", "0003:27B8:01ED.0001")
+	require.NoError(t, os.MkdirAll(dev, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(usbDir, "serial"), []byte("2000ABCD\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(usbDir, "product"), []byte("blink(1) mk2\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dev, "uevent"), []byte("HID_ID=0003:000027B8:000001ED\n"), 0644))
+
+	class := filepath.Join(root, "class", "hidraw", "hidraw3")
+	require.NoError(t, os.MkdirAll(class, 0755))
+	require.NoError(t, os.Symlink(dev, filepath.Join(class, "device")))
+
+	defer func(path string) { sysfsHidrawPath = path }(sysfsHidrawPath)
+	sysfsHidrawPath = filepath.Dir(class)
+
+	devices, err := List()
+	require.NoError(t, err)
+	assert.Equal(t, []DeviceInfo{{
+		Path:      "27b8:01ed:02.04",
+		VendorID:  VendorNumber,
+		ProductID: ProductNumber,
+		Serial:    "2000ABCD",
+		Product:   "blink(1) mk2",
+	}}, devices)
+}
+
+func TestHidrawErrorClasses(t *testing.T) {
+	di := DeviceInfo{Path: "27b8:01ed:02"}
+	data := []struct {
+		errno syscall.Errno
+		class error
+	}{
+		{syscall.EACCES, ErrPermissionDenied},
+		{syscall.ENODEV, ErrDisconnected},
+		{syscall.EBUSY, ErrBusy},
+		{syscall.ETIMEDOUT, ErrTimeout},
+		{syscall.EPIPE, ErrPipe},
+	}
+
+	for _, d := range data {
+		err := di.error(d.errno)
+		assert.True(t, errors.Is(err, d.class), "%s should be %s", d.errno, d.class)
+		assert.True(t, errors.Is(err, d.errno))
+		assert.Equal(t, int(d.errno), err.(*DeviceError).Code)
+	}
+
+	assert.False(t, errors.Is(di.error(syscall.EIO), ErrDisconnected))
+}
+
+func TestIoctlOnClosedDevice(t *testing.T) {
+	f, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+
+	d := &usbDevice{file: f, info: DeviceInfo{Path: "27b8:01ed:02"}, busy: make(chan struct{}, 1)}
+
+	// /dev/null is no hidraw device so the ioctl itself fails
+	err = d.WriteReport(context.Background(), make([]byte, 8))
+	assert.True(t, errors.Is(err, syscall.ENOTTY))
+
+	require.NoError(t, d.Close())
+	assert.Error(t, d.WriteReport(context.Background(), make([]byte, 8)))
+}
+
+func TestListVirtualDevices(t *testing.T) {
+	root := t.TempDir()
+	dev := filepath.Join(root, "devices", "virtual", "misc", "uhid", "0006:27B8:01ED.0002")
+	require.NoError(t, os.MkdirAll(dev, 0755))
+	uevent := "HID_ID=0006:000027B8:000001ED\nHID_NAME=blinktest\nHID_PHYS=blinktest-1\nHID_UNIQ=BT0001\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dev, "uevent"), []byte(uevent), 0644))
+
+	class := filepath.Join(root, "class", "hidraw", "hidraw5")
+	require.NoError(t, os.MkdirAll(class, 0755))
+	require.NoError(t, os.Symlink(dev, filepath.Join(class, "device")))
+
+	defer func(path string) { sysfsHidrawPath = path }(sysfsHidrawPath)
+	sysfsHidrawPath = filepath.Dir(class)
+
+	devices, err := List()
+	require.NoError(t, err)
+	assert.Equal(t, []DeviceInfo{{
+		Path:      "27b8:01ed:blinktest-1",
+		VendorID:  VendorNumber,
+		ProductID: ProductNumber,
+		Serial:    "BT0001",
+		Product:   "blinktest",
+	}}, devices)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dev, "uevent"), []byte("HID_ID=0006:000027B8:000001ED\n"), 0644))
+	devices, err = List()
+	require.NoError(t, err)
+	assert.Equal(t, "27b8:01ed:hidraw5", devices[0].Path)
+}
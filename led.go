@@ -2,6 +2,7 @@
 package blink
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -20,50 +21,84 @@ const (
 // ErrNoDevice is the error that New() returns if no connected blink(1) device was found.
 var ErrNoDevice = errors.New("could not find blink1 device")
 
-// LED represents a locally connected blink(1) USB device.
+// LED represents a blink(1) device which is either connected locally via USB
+// or reachable through any other Transport.
+//
+// An LED is safe for concurrent use by multiple goroutines. All transfers to the
+// device are serialized so a Read always returns the response to its own request,
+// even if other goroutines are changing the color at the same time.
+// The ID field must not be changed while the LED is in use by other goroutines.
+// Use Channel to get a separate LED for each individually addressable LED of a device.
 type LED struct {
-	*usbDevice
+	*conn
 
 	ID byte // ID signals which LED to address: 0=all, 1=led#1, 2=led#2, etc. (mk2 only)
 }
 
 // New connects to a locally connected blink(1) USB device.
 // The caller must call Close when it is done with this LED.
-// The function returns a NoDeviceErr if no blink(1) device can be found
+// The function returns ErrNoDevice if no blink(1) device can be found
 // or another error if the device could not be opened.
 //
-// Multiple connected devices are not yet supported and the library will just
-// pick one of them to talk to.
-func New() (*LED, error) {
-	l := LED{}
-
-	found := false
-	var di usbDeviceInfo
-	for di = range usbDevices() {
-		if di.vendorID == VendorNumber && di.productID == ProductNumber {
-			found = true
-			break
-		}
+// If multiple devices are connected New picks the first one returned by List
+// unless another device is selected via WithSerial, WithPath or WithIndex.
+// If no device matches the selection the returned error wraps ErrNoDevice.
+// Examples:
+//     led, err := blink.New()
+//     led, err := blink.New(blink.WithSerial("2000ABCD"))
+func New(opts ...Option) (*LED, error) {
+	o := newOptions(opts)
+	devices, err := List()
+	if err != nil {
+		return nil, err
 	}
-	if !found {
-		return nil, ErrNoDevice
+
+	di, err := o.selector(devices)
+	if err != nil {
+		return nil, err
 	}
 
-	var err error
-	l.usbDevice, err = di.open()
+	dev, err := di.open()
 	if err != nil {
-		return nil, fmt.Errorf("could not open blink1 device %+v: %s", l.usbDevice.info, err)
+		return nil, fmt.Errorf("could not open blink1 device %s: %w", di, err)
+	}
+
+	var t Transport = dev
+	if o.reconnect {
+		t = newReconnectingTransport(di, dev, o.reconnectWait)
 	}
 
-	return &l, nil
+	l := NewLED(t, opts...)
+	l.info = di
+	return l, nil
+}
+
+// NewLED creates a new LED that communicates with a blink(1) device via the
+// given Transport. The LED takes ownership of t and closes it when the LED is
+// closed. Options that select or reconnect a device have no effect here.
+func NewLED(t Transport, opts ...Option) *LED {
+	o := newOptions(opts)
+	c := newConn(t)
+	c.retry = o.retry
+	if o.rateLimit > 0 {
+		c.scheduler = newScheduler(c, o.rateLimit)
+	}
+
+	return &LED{conn: c}
+}
+
+// Info returns information about the USB device this LED is connected to.
+// It returns an empty DeviceInfo if the LED was created via NewLED.
+func (l *LED) Info() DeviceInfo {
+	return l.info
 }
 
 // Close implements io.Closer by closing to the connection to the USB device.
 // The function is idempotent and can be called on already closed or never
 // opened devices.
 func (l *LED) Close() error {
-	if l != nil && l.usbDevice != nil {
-		l.close()
+	if l != nil && l.conn != nil {
+		return l.close()
 	}
 
 	return nil
@@ -94,10 +129,28 @@ func (l *LED) SetRGB(r, g, b byte) error {
 	return l.Set(Color{r, g, b})
 }
 
+// Channel returns an LED which addresses only the LED with the given index
+// of this device: 0=all, 1=led#1, 2=led#2, etc. (mk2 only).
+// The returned LED shares the connection with l, so closing either of them
+// closes the connection of both.
+// Example:
+//     top, bottom := led.Channel(1), led.Channel(2)
+//     top.Set(blink.Red)
+//     bottom.Fade(blink.Blue, time.Second)
+func (l *LED) Channel(n byte) *LED {
+	return &LED{conn: l.conn, ID: n}
+}
+
 // Set lights up the blink(1) with the specified color immediately.
+// If ID is not zero and the device can not address its LEDs individually an UnsupportedError is returned.
 func (l *LED) Set(c Color) error {
-	_, err := l.write(&setRGBCommand{c})
-	return err
+	return l.SetContext(context.Background(), c)
+}
+
+// SetContext is like Set but aborts when ctx is done.
+// The deadline of ctx is used as timeout of the USB transfer.
+func (l *LED) SetContext(ctx context.Context, c Color) error {
+	return l.writeColor(ctx, &setRGBCommand{c})
 }
 
 // FadeRGB is a handy shortcut to LED.Fade(Color{r, g, b}, d)
@@ -106,11 +159,106 @@ func (l *LED) FadeRGB(r, g, b byte, d time.Duration) error {
 }
 
 // Fade lights up the blink(1) with the specified RGB color, fading to that color over a specified duration.
+// If ID is not zero and the device can not address its LEDs individually an UnsupportedError is returned.
 func (l *LED) Fade(c Color, d time.Duration) error {
-	_, err := l.write(&fadeRGBCommand{Color: c, duration: d, n: l.ID})
+	return l.FadeContext(context.Background(), c, d)
+}
+
+// FadeContext is like Fade but aborts when ctx is done.
+// The deadline of ctx is used as timeout of the USB transfer.
+// Note that the fade itself is executed by the device and is not affected by ctx.
+func (l *LED) FadeContext(ctx context.Context, c Color, d time.Duration) error {
+	return l.writeColor(ctx, &fadeRGBCommand{Color: c, duration: d})
+}
+
+// SetEach lights up each LED of the device with its own color immediately.
+// The first color is used for led#1, the second for led#2 and so on.
+// An UnsupportedError is returned if the device can not address its LEDs individually.
+// Example:
+//     led.SetEach(blink.Red, blink.Blue) // red on top, blue at the bottom
+func (l *LED) SetEach(colors ...Color) error {
+	return l.SetEachContext(context.Background(), colors...)
+}
+
+// SetEachContext is like SetEach but aborts when ctx is done.
+func (l *LED) SetEachContext(ctx context.Context, colors ...Color) error {
+	return l.FadeEachContext(ctx, 0, colors...)
+}
+
+// FadeEach lets each LED of the device fade to its own color over the given duration.
+// The first color is used for led#1, the second for led#2 and so on.
+// An UnsupportedError is returned if the device can not address its LEDs individually.
+func (l *LED) FadeEach(d time.Duration, colors ...Color) error {
+	return l.FadeEachContext(context.Background(), d, colors...)
+}
+
+// FadeEachContext is like FadeEach but aborts when ctx is done.
+func (l *LED) FadeEachContext(ctx context.Context, d time.Duration, colors ...Color) error {
+	caps, err := l.CapabilitiesContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !caps.PerLEDAddressing {
+		return &UnsupportedError{Op: "addressing individual LEDs", Generation: caps.Generation}
+	}
+
+	if len(colors) > caps.LEDs {
+		return fmt.Errorf("got %d colors but the device has only %d LEDs", len(colors), caps.LEDs)
+	}
+
+	cmds := make([]command, len(colors))
+	for i, c := range colors {
+		cmds[i] = &fadeRGBCommand{Color: c, duration: d, n: byte(i + 1)}
+	}
+
+	if l.scheduler != nil {
+		return l.scheduler.submit(cmds...)
+	}
+
+	// all commands are sent at once to minimize the time between the updates of the LEDs
+	return l.writeAll(ctx, cmds...)
+}
+
+// writeColor sends the given set or fade command to the LED with the index ID
+// or queues it if the write scheduler is enabled.
+// Commands which explicitly address another LED are sent as they are.
+func (l *LED) writeColor(ctx context.Context, cmd command) error {
+	cmd = l.address(cmd)
+	if ledIndex(cmd) != 0 {
+		if err := l.require(ctx, "addressing individual LEDs", func(c Capabilities) bool { return c.PerLEDAddressing }); err != nil {
+			return err
+		}
+	}
+
+	if l.scheduler != nil {
+		return l.scheduler.submit(cmd)
+	}
+
+	_, err := l.write(ctx, cmd)
 	return err
 }
 
+// address returns a command which addresses the LED with the index ID
+// if cmd addresses all LEDs. The set command can not address individual LEDs
+// so it is replaced by a fade command without duration.
+func (l *LED) address(cmd command) command {
+	if l.ID == 0 {
+		return cmd
+	}
+
+	switch c := cmd.(type) {
+	case *setRGBCommand:
+		return &fadeRGBCommand{Color: c.Color, n: l.ID}
+	case *fadeRGBCommand:
+		if c.n == 0 {
+			return &fadeRGBCommand{Color: c.Color, duration: c.duration, n: l.ID}
+		}
+	}
+
+	return cmd
+}
+
 // ReadRGB is deprecated and will be removed in v2. Use LED.Read() instead.
 // ReadRGB reads the currently active color of the blink(1) device.
 // Will return meaningful results for mk2 devices only.
@@ -119,9 +267,21 @@ func (l *LED) ReadRGB() (Color, error) {
 }
 
 // Read reads the currently active color of the blink(1) device.
-// Will return meaningful results for mk2 devices only.
+// If ID is not zero the color of the LED with that index is returned.
+// Reading the color is supported by mk2 devices and later.
+// For older devices an UnsupportedError is returned.
 func (l *LED) Read() (Color, error) {
-	buf, err := l.read(&readRGBCommand{})
+	return l.ReadContext(context.Background())
+}
+
+// ReadContext is like Read but aborts when ctx is done.
+// The deadline of ctx is used as timeout of the USB transfers.
+func (l *LED) ReadContext(ctx context.Context) (Color, error) {
+	if err := l.require(ctx, "reading the current color", func(c Capabilities) bool { return c.Readback }); err != nil {
+		return Color{}, err
+	}
+
+	buf, err := l.read(ctx, &readRGBCommand{n: l.ID})
 	if err != nil {
 		return Color{}, err
 	}
@@ -1,10 +1,45 @@
 package blink
 
 import (
+	"context"
+	"errors"
 	"io"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// fakeTransport records all written reports and answers reads with a fixed
+// response for the command of the last written report.
+type fakeTransport struct {
+	written   [][]byte
+	responses map[byte][]byte
+	closed    bool
+}
+
+// mk2Version is the response of a blink(1) mk2 with firmware v2.04 to a version request.
+var mk2Version = []byte{0x01, 'v', 0x00, '2', '4', 0x00, 0x00, 0x00}
+
+func (t *fakeTransport) WriteReport(ctx context.Context, report []byte) error {
+	t.written = append(t.written, append([]byte(nil), report...))
+	return nil
+}
+
+func (t *fakeTransport) ReadReport(ctx context.Context, report []byte) error {
+	if len(t.written) > 0 {
+		copy(report, t.responses[t.written[len(t.written)-1][1]])
+	}
+	return nil
+}
+
+func (t *fakeTransport) Close() error {
+	t.closed = true
+	return nil
+}
+
 func TestLEDImplementsIoCloser(t *testing.T) {
 	var _ io.Closer = new(LED)
 }
@@ -13,3 +48,229 @@ func TestDontPanicWhenClosingNilLEDs(t *testing.T) {
 	var led *LED
 	led.Close()
 }
+
+func TestLEDWritesReportsToTransport(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{'v': mk2Version}}
+	led := NewLED(tr)
+	led.ID = 2
+
+	assert.NoError(t, led.Set(Color{1, 2, 3}))
+	assert.NoError(t, led.Fade(Color{4, 5, 6}, 100*time.Millisecond))
+	assert.Equal(t, [][]byte{
+		{0x01, 'v', 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		{0x01, 'c', 0x01, 0x02, 0x03, 0x00, 0x00, 0x02},
+		{0x01, 'c', 0x04, 0x05, 0x06, 0x00, 0x0a, 0x02},
+	}, tr.written)
+
+	assert.NoError(t, led.Close())
+	assert.True(t, tr.closed)
+}
+
+func TestLEDReadsColorFromTransport(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{
+		'v': mk2Version,
+		'r': {0x01, 'r', 0x10, 0x20, 0x30, 0x00, 0x00, 0x00},
+	}}
+	led := NewLED(tr)
+
+	c, err := led.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, Color{R: 0x10, G: 0x20, B: 0x30}, c)
+	assert.Equal(t, []byte{0x01, 'r', 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, tr.written[1])
+}
+
+// exclusiveTransport fails the test if a request and its response are interleaved
+// with another transfer.
+type exclusiveTransport struct {
+	t       *testing.T
+	mu      sync.Mutex
+	pending []byte // the request which has been written but not yet read
+}
+
+func (tr *exclusiveTransport) WriteReport(ctx context.Context, report []byte) error {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if tr.pending != nil {
+		tr.t.Errorf("%q was written before the response to %q has been read", report[1], tr.pending[1])
+	}
+
+	if report[1] == 'r' || report[1] == 'v' {
+		tr.pending = append([]byte(nil), report...)
+	}
+
+	return nil
+}
+
+func (tr *exclusiveTransport) ReadReport(ctx context.Context, report []byte) error {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	copy(report, mk2Version)
+	report[1] = tr.pending[1]
+	tr.pending = nil
+	return nil
+}
+
+func (tr *exclusiveTransport) Close() error {
+	return nil
+}
+
+func TestLEDIsSafeForConcurrentUse(t *testing.T) {
+	led := NewLED(&exclusiveTransport{t: t})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.NoError(t, led.Fade(Red, time.Millisecond))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, err := led.Read()
+				assert.NoError(t, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// blockingTransport blocks each transfer until its context is done or release is closed.
+type blockingTransport struct {
+	fakeTransport
+	release chan struct{}
+}
+
+func (t *blockingTransport) WriteReport(ctx context.Context, report []byte) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.release:
+		return nil
+	}
+}
+
+func TestContextDeadlines(t *testing.T) {
+	tr := &blockingTransport{release: make(chan struct{})}
+	led := NewLED(tr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	blocked := make(chan error)
+	go func() { blocked <- led.SetContext(context.Background(), Red) }() // blocks the device until it is released
+	t.Cleanup(func() {
+		close(tr.release)
+		assert.NoError(t, <-blocked)
+	})
+	time.Sleep(time.Millisecond)
+
+	err := led.FadeContext(ctx, Red, time.Second)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestContextVariantsAbortWhenDone(t *testing.T) {
+	led := NewLED(&blockingTransport{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	calls := map[string]func() error{
+		"Version":        func() error { _, err := led.VersionContext(ctx); return err },
+		"PlayPattern":    func() error { return led.PlayPatternContext(ctx, 0) },
+		"PausePattern":   func() error { return led.PausePatternContext(ctx) },
+		"SetPatternLine": func() error { return led.SetPatternLineContext(ctx, 0, PatternLine{}) },
+		"SavePatterns":   func() error { return led.SavePatternsContext(ctx) },
+		"Tickle":         func() error { return led.TickleContext(ctx, ServerdownConfig{Timeout: time.Second}) },
+		"ReadEEPROM":     func() error { _, err := led.ReadEEPROMContext(ctx, 0); return err },
+		"ReadNote":       func() error { _, err := led.ReadNoteContext(ctx, 0); return err },
+		"SelfTest":       func() error { return led.SelfTestContext(ctx) },
+		"Diagnose":       func() error { return led.DiagnoseContext(ctx).Err() },
+	}
+
+	for name, call := range calls {
+		assert.True(t, errors.Is(call(), context.DeadlineExceeded), name)
+	}
+}
+
+func TestPlayContextStopsWhenCancelled(t *testing.T) {
+	led := NewLED(&fakeTransport{})
+	s, _ := NewSequence().Set(Red, time.Hour).Loop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	err := s.PlayContext(ctx, led)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestChannelsAddressIndividualLEDs(t *testing.T) {
+	tr := newColorTransport()
+	led := NewLED(tr)
+	top, bottom := led.Channel(1), led.Channel(2)
+
+	require.NoError(t, top.Set(Red))
+	require.NoError(t, bottom.Fade(Blue, 0))
+	assert.Equal(t, Red, tr.colors[1])
+	assert.Equal(t, Blue, tr.colors[2])
+
+	c, err := bottom.Read()
+	require.NoError(t, err)
+	assert.Equal(t, Blue, c)
+
+	require.NoError(t, top.Close())
+	assert.True(t, tr.closed, "channels share the connection of the device")
+}
+
+func TestChannelsAreUnsupportedOnMK1(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{'v': mk1Version}}
+	led := NewLED(tr)
+
+	assert.True(t, errors.Is(led.Channel(1).Set(Red), ErrUnsupported))
+	assert.True(t, errors.Is(led.SetEach(Red, Blue), ErrUnsupported))
+	assert.NoError(t, led.Channel(0).Set(Red))
+}
+
+func TestSetEach(t *testing.T) {
+	tr := newColorTransport()
+	led := NewLED(tr)
+
+	require.NoError(t, led.SetEach(Red, Blue))
+	assert.Equal(t, Red, tr.colors[1])
+	assert.Equal(t, Blue, tr.colors[2])
+
+	require.NoError(t, led.FadeEach(100*time.Millisecond, Green))
+	assert.Equal(t, []byte{0x01, 'c', 0x00, 0xff, 0x00, 0x00, 0x0a, 0x01}, tr.written[len(tr.written)-1])
+
+	assert.Error(t, led.SetEach(Red, Green, Blue))
+}
+
+func TestSequenceAddressesLEDs(t *testing.T) {
+	tr := newColorTransport()
+	led := NewLED(tr)
+
+	s := NewSequence().
+		SetLED(1, Red, 0).
+		FadeLED(2, Blue, 0).
+		Set(Green, 0)
+
+	require.NoError(t, s.Play(led))
+	assert.Equal(t, [][]byte{
+		{0x01, 'v', 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		{0x01, 'c', 0xff, 0x00, 0x00, 0x00, 0x00, 0x01},
+		{0x01, 'c', 0x00, 0x00, 0xff, 0x00, 0x00, 0x02},
+		{0x01, 'n', 0x00, 0xff, 0x00, 0x00, 0x00, 0x00},
+	}, tr.written)
+
+	// frames without an explicit LED address the LED the sequence is played on
+	require.NoError(t, NewSequence().Set(Red, 0).Play(led.Channel(2)))
+	assert.Equal(t, []byte{0x01, 'c', 0xff, 0x00, 0x00, 0x00, 0x00, 0x02}, tr.written[len(tr.written)-1])
+}
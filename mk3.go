@@ -0,0 +1,178 @@
+package blink
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+)
+
+// The user notes of mk3 devices can be used to store arbitrary data on the device,
+// e.g. to label it.
+const (
+	NoteSize  = 50 // the size of a single user note in bytes
+	NoteCount = 10 // the number of user notes of a device
+)
+
+// chipIDSize is the length of the unique chip ID of mk3 devices in bytes.
+const chipIDSize = 8
+
+// BootMode determines what a mk3 device does when it is powered on.
+type BootMode byte
+
+// The boot modes of mk3 devices.
+const (
+	BootNormal BootMode = iota // play the startup pattern unless a host talks to the device
+	BootPlay                   // always play the startup pattern
+	BootOff                    // stay dark until a host talks to the device
+)
+
+// StartupParams describe what a mk3 device does when it is powered on.
+// The device plays the pattern lines from Start to End (inclusive) Count times
+// or forever if Count is zero.
+type StartupParams struct {
+	Mode       BootMode
+	Start, End int
+	Count      int
+}
+
+// SetStartupParams configures what the device does when it is powered on.
+// Setting the startup parameters is supported by mk3 devices only.
+func (l *LED) SetStartupParams(p StartupParams) error {
+	return l.SetStartupParamsContext(context.Background(), p)
+}
+
+// SetStartupParamsContext is like SetStartupParams but aborts when ctx is done.
+func (l *LED) SetStartupParamsContext(ctx context.Context, p StartupParams) error {
+	caps, err := l.CapabilitiesContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if caps.Generation < MK3 {
+		return &UnsupportedError{Op: "setting the startup parameters", Generation: caps.Generation}
+	}
+
+	if err := validatePatternRange(caps, p.Start, p.End); err != nil {
+		return err
+	}
+
+	if p.Count < 0 || p.Count > 255 {
+		return fmt.Errorf("startup pattern count %d is out of range [0, 255]", p.Count)
+	}
+
+	_, err = l.write(ctx, &startupParamsCommand{
+		mode:  p.Mode,
+		start: byte(p.Start),
+		end:   byte(p.End),
+		count: byte(p.Count),
+	})
+
+	return err
+}
+
+// StartupParams reads what the device does when it is powered on.
+// Reading the startup parameters is supported by mk3 devices only.
+func (l *LED) StartupParams() (StartupParams, error) {
+	return l.StartupParamsContext(context.Background())
+}
+
+// StartupParamsContext is like StartupParams but aborts when ctx is done.
+func (l *LED) StartupParamsContext(ctx context.Context) (StartupParams, error) {
+	if err := l.requireMK3(ctx, "reading the startup parameters"); err != nil {
+		return StartupParams{}, err
+	}
+
+	buf, err := l.read(ctx, &readStartupParamsCommand{})
+	if err != nil {
+		return StartupParams{}, err
+	}
+
+	return StartupParams{
+		Mode:  BootMode(buf[2]),
+		Start: int(buf[3]),
+		End:   int(buf[4]),
+		Count: int(buf[5]),
+	}, nil
+}
+
+// WriteNote stores the given data in the user note with the given id.
+// The data must not be longer than NoteSize and is padded with zeros.
+// Writing user notes is supported by mk3 devices only.
+// Example:
+//     err := led.WriteNote(0, []byte("build server #3"))
+func (l *LED) WriteNote(id int, data []byte) error {
+	return l.WriteNoteContext(context.Background(), id, data)
+}
+
+// WriteNoteContext is like WriteNote but aborts when ctx is done.
+func (l *LED) WriteNoteContext(ctx context.Context, id int, data []byte) error {
+	if err := l.requireNote(ctx, "writing user notes", id); err != nil {
+		return err
+	}
+
+	if len(data) > NoteSize {
+		return fmt.Errorf("note has %d bytes but at most %d bytes can be stored", len(data), NoteSize)
+	}
+
+	_, err := l.write(ctx, &writeNoteCommand{id: byte(id), data: data})
+	return err
+}
+
+// ReadNote reads the user note with the given id.
+// Trailing zeros are removed from the returned data.
+// Reading user notes is supported by mk3 devices only.
+func (l *LED) ReadNote(id int) ([]byte, error) {
+	return l.ReadNoteContext(context.Background(), id)
+}
+
+// ReadNoteContext is like ReadNote but aborts when ctx is done.
+func (l *LED) ReadNoteContext(ctx context.Context, id int) ([]byte, error) {
+	if err := l.requireNote(ctx, "reading user notes", id); err != nil {
+		return nil, err
+	}
+
+	buf, err := l.read(ctx, &readNoteCommand{id: byte(id)})
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimRight(buf[3:3+NoteSize], "\x00"), nil
+}
+
+// ChipID reads the unique ID of the microcontroller of the device as hex string.
+// In contrast to the USB serial number the chip ID can not be changed.
+// Reading the chip ID is supported by mk3 devices only.
+func (l *LED) ChipID() (string, error) {
+	return l.ChipIDContext(context.Background())
+}
+
+// ChipIDContext is like ChipID but aborts when ctx is done.
+func (l *LED) ChipIDContext(ctx context.Context) (string, error) {
+	if err := l.requireMK3(ctx, "reading the chip ID"); err != nil {
+		return "", err
+	}
+
+	buf, err := l.read(ctx, &readChipIDCommand{})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf[3 : 3+chipIDSize]), nil
+}
+
+func (l *LED) requireMK3(ctx context.Context, op string) error {
+	return l.require(ctx, op, func(c Capabilities) bool { return c.Generation >= MK3 })
+}
+
+func (l *LED) requireNote(ctx context.Context, op string, id int) error {
+	if err := l.requireMK3(ctx, op); err != nil {
+		return err
+	}
+
+	if id < 0 || id >= NoteCount {
+		return fmt.Errorf("note id %d is out of range [0, %d]", id, NoteCount-1)
+	}
+
+	return nil
+}
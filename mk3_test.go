@@ -0,0 +1,73 @@
+package blink
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mk3Version is the response of a blink(1) mk3 with firmware v3.02 to a version request.
+var mk3Version = []byte{0x01, 'v', 0x00, '3', '2', 0x00, 0x00, 0x00}
+
+func TestStartupParams(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{
+		'v': mk3Version,
+		'b': {0x01, 'b', 0x02, 0x01, 0x05, 0x03, 0x00, 0x00},
+	}}
+	led := NewLED(tr)
+
+	require.NoError(t, led.SetStartupParams(StartupParams{Mode: BootPlay, Start: 2, End: 6, Count: 0}))
+	assert.Equal(t, []byte{0x01, 'B', 0x01, 0x02, 0x06, 0x00, 0x00, 0x00}, tr.written[1])
+
+	p, err := led.StartupParams()
+	require.NoError(t, err)
+	assert.Equal(t, StartupParams{Mode: BootOff, Start: 1, End: 5, Count: 3}, p)
+
+	assert.Error(t, led.SetStartupParams(StartupParams{Start: 5, End: 2}))
+	assert.Error(t, led.SetStartupParams(StartupParams{Count: 256}))
+}
+
+func TestNotes(t *testing.T) {
+	response := make([]byte, report2Size)
+	copy(response, []byte{0x02, 'f', 0x04, 'd', 'e', 'a', 'd'})
+	tr := &fakeTransport{responses: map[byte][]byte{'v': mk3Version, 'f': response}}
+	led := NewLED(tr)
+
+	require.NoError(t, led.WriteNote(4, []byte("build server #3")))
+	assert.Len(t, tr.written[1], report2Size)
+	assert.Equal(t, append([]byte{0x02, 'F', 0x04}, "build server #3"...), tr.written[1][:18])
+
+	note, err := led.ReadNote(4)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("dead"), note)
+
+	assert.Error(t, led.WriteNote(NoteCount, nil))
+	assert.Error(t, led.WriteNote(0, make([]byte, NoteSize+1)))
+	_, err = led.ReadNote(-1)
+	assert.Error(t, err)
+}
+
+func TestChipID(t *testing.T) {
+	response := make([]byte, report2Size)
+	copy(response, []byte{0x02, 'U', 0x00, 0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef})
+	tr := &fakeTransport{responses: map[byte][]byte{'v': mk3Version, 'U': response}}
+
+	id, err := NewLED(tr).ChipID()
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef", id)
+}
+
+func TestMK3CommandsAreUnsupportedOnMK2(t *testing.T) {
+	led := NewLED(&fakeTransport{responses: map[byte][]byte{'v': mk2Version}})
+
+	_, err := led.StartupParams()
+	assert.True(t, errors.Is(err, ErrUnsupported))
+	assert.True(t, errors.Is(led.SetStartupParams(StartupParams{}), ErrUnsupported))
+	assert.True(t, errors.Is(led.WriteNote(0, nil), ErrUnsupported))
+	_, err = led.ReadNote(0)
+	assert.True(t, errors.Is(err, ErrUnsupported))
+	_, err = led.ChipID()
+	assert.True(t, errors.Is(err, ErrUnsupported))
+}
@@ -0,0 +1,101 @@
+package blink
+
+import (
+	"fmt"
+	"time"
+)
+
+// An Option can be passed to New or NewLED to configure which device is opened and how.
+type Option func(*options)
+
+type options struct {
+	selector func([]DeviceInfo) (DeviceInfo, error)
+
+	reconnect     bool
+	reconnectWait time.Duration
+
+	retry     RetryPolicy
+	rateLimit time.Duration
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{selector: selectIndex(0)}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithSerial instructs New to open the device with the given USB serial number.
+// Since the serial number is stored on the device this is the most reliable way to
+// select the same device across reboots and after it was plugged into another port.
+func WithSerial(serial string) Option {
+	return func(o *options) {
+		o.selector = func(devices []DeviceInfo) (DeviceInfo, error) {
+			for _, di := range devices {
+				if di.Serial == serial {
+					return di, nil
+				}
+			}
+
+			return DeviceInfo{}, fmt.Errorf("%w with serial number %q", ErrNoDevice, serial)
+		}
+	}
+}
+
+// WithPath instructs New to open the device with the given path (see DeviceInfo.Path).
+// The path stays the same across reboots as long as the device is connected to the same USB port.
+func WithPath(path string) Option {
+	return func(o *options) {
+		o.selector = func(devices []DeviceInfo) (DeviceInfo, error) {
+			for _, di := range devices {
+				if di.Path == path {
+					return di, nil
+				}
+			}
+
+			return DeviceInfo{}, fmt.Errorf("%w with path %q", ErrNoDevice, path)
+		}
+	}
+}
+
+// WithIndex instructs New to open the i-th device that is returned by List.
+// Because List sorts the devices by their path the index stays the same across
+// reboots as long as the same devices are connected to the same USB ports.
+func WithIndex(i int) Option {
+	return func(o *options) {
+		o.selector = selectIndex(i)
+	}
+}
+
+func selectIndex(i int) func([]DeviceInfo) (DeviceInfo, error) {
+	return func(devices []DeviceInfo) (DeviceInfo, error) {
+		if len(devices) == 0 {
+			return DeviceInfo{}, ErrNoDevice
+		}
+
+		if i < 0 || i >= len(devices) {
+			return DeviceInfo{}, fmt.Errorf("%w with index %d (found %d devices)", ErrNoDevice, i, len(devices))
+		}
+
+		return devices[i], nil
+	}
+}
+
+// WithReconnect enables the resilient mode in which the LED automatically
+// reconnects to the device if it has been unplugged. The device is identified by
+// its serial number so it may also be plugged into another USB port.
+// Once the device is available again its last color or fade is restored.
+//
+// While the device is disconnected all calls to the LED block until the device
+// is back or until maxWait has passed. If maxWait is zero the calls block until
+// the device is back or the LED is closed. Since a Sequence that is played on
+// the LED is blocked as well, it resumes at the same frame after the device
+// has been reconnected.
+func WithReconnect(maxWait time.Duration) Option {
+	return func(o *options) {
+		o.reconnect = true
+		o.reconnectWait = maxWait
+	}
+}
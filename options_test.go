@@ -0,0 +1,43 @@
+package blink
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceSelection(t *testing.T) {
+	devices := []DeviceInfo{
+		{Path: "27b8:01ed:01", Serial: "2000AAAA"},
+		{Path: "27b8:01ed:02.04", Serial: "2000BBBB"},
+	}
+
+	data := []struct {
+		opts []Option
+		want DeviceInfo
+	}{
+		{nil, devices[0]},
+		{[]Option{WithIndex(1)}, devices[1]},
+		{[]Option{WithSerial("2000BBBB")}, devices[1]},
+		{[]Option{WithPath("27b8:01ed:01")}, devices[0]},
+	}
+
+	for _, d := range data {
+		di, err := newOptions(d.opts).selector(devices)
+		assert.NoError(t, err)
+		assert.Equal(t, d.want, di)
+	}
+}
+
+func TestDeviceSelectionWithoutMatch(t *testing.T) {
+	devices := []DeviceInfo{{Path: "27b8:01ed:01", Serial: "2000AAAA"}}
+
+	for _, opt := range []Option{WithIndex(1), WithIndex(-1), WithSerial("foo"), WithPath("foo")} {
+		_, err := newOptions([]Option{opt}).selector(devices)
+		assert.True(t, errors.Is(err, ErrNoDevice), "expected ErrNoDevice but got %v", err)
+	}
+
+	_, err := newOptions(nil).selector(nil)
+	assert.Equal(t, ErrNoDevice, err)
+}
@@ -0,0 +1,223 @@
+package blink
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// A PatternLine is a single line of the color pattern table of a device.
+// When the pattern is played, the device fades to Color over FadeDuration
+// and then continues with the next line.
+type PatternLine struct {
+	Color        Color
+	FadeDuration time.Duration // the duration is stored in steps of 10ms
+	LEDIndex     byte          // which LED to address: 0=all, 1=led#1, 2=led#2, etc. (mk2 only)
+}
+
+// PlayState describes the state of the on-device pattern playback.
+type PlayState struct {
+	Playing    bool // whether the pattern is currently playing
+	Position   int  // the pattern line which is currently played
+	Start, End int  // the range of pattern lines that is played
+	Count      int  // the remaining number of loops or 0 if the range loops forever
+}
+
+// PlayPattern lets the device play its color pattern starting at the given
+// line. In contrast to a Sequence, the pattern is played by the device itself
+// and keeps playing after the process has exited.
+func (l *LED) PlayPattern(start int) error {
+	return l.PlayPatternContext(context.Background(), start)
+}
+
+// PlayPatternContext is like PlayPattern but aborts when ctx is done.
+func (l *LED) PlayPatternContext(ctx context.Context, start int) error {
+	caps, err := l.CapabilitiesContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := validatePatternRange(caps, start, start); err != nil {
+		return err
+	}
+
+	_, err = l.write(ctx, &playCommand{play: true, start: byte(start)})
+	return err
+}
+
+// PlayPatternLoop lets the device play the pattern lines from start to end
+// (inclusive) count times. If count is zero the lines are played forever.
+// Playing a loop is supported by mk2 devices and later.
+func (l *LED) PlayPatternLoop(start, end, count int) error {
+	return l.PlayPatternLoopContext(context.Background(), start, end, count)
+}
+
+// PlayPatternLoopContext is like PlayPatternLoop but aborts when ctx is done.
+func (l *LED) PlayPatternLoopContext(ctx context.Context, start, end, count int) error {
+	caps, err := l.CapabilitiesContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if caps.Generation < MK2 {
+		return &UnsupportedError{Op: "playing a pattern loop", Generation: caps.Generation}
+	}
+
+	if err := validatePatternRange(caps, start, end); err != nil {
+		return err
+	}
+
+	if count < 0 || count > 255 {
+		return fmt.Errorf("pattern loop count %d is out of range [0, 255]", count)
+	}
+
+	_, err = l.write(ctx, &playCommand{
+		play:  true,
+		start: byte(start),
+		end:   byte(end),
+		count: byte(count),
+	})
+
+	return err
+}
+
+// PausePattern stops the on-device pattern playback at the current line.
+func (l *LED) PausePattern() error {
+	return l.PausePatternContext(context.Background())
+}
+
+// PausePatternContext is like PausePattern but aborts when ctx is done.
+func (l *LED) PausePatternContext(ctx context.Context) error {
+	_, err := l.write(ctx, &playCommand{play: false})
+	return err
+}
+
+// PlayState reads the state of the on-device pattern playback.
+// Reading the play state is supported by mk2 devices and later.
+func (l *LED) PlayState() (PlayState, error) {
+	return l.PlayStateContext(context.Background())
+}
+
+// PlayStateContext is like PlayState but aborts when ctx is done.
+func (l *LED) PlayStateContext(ctx context.Context) (PlayState, error) {
+	if err := l.require(ctx, "reading the play state", func(c Capabilities) bool { return c.Generation >= MK2 }); err != nil {
+		return PlayState{}, err
+	}
+
+	buf, err := l.read(ctx, &readPlayStateCommand{})
+	if err != nil {
+		return PlayState{}, err
+	}
+
+	return PlayState{
+		Playing:  buf[2] != 0,
+		Start:    int(buf[3]),
+		End:      int(buf[4]),
+		Count:    int(buf[5]),
+		Position: int(buf[6]),
+	}, nil
+}
+
+// SetPatternLine writes the line at the given position of the color pattern table.
+// The pattern table is kept in RAM until it is saved via SavePatterns.
+// If the LEDIndex of the line is not zero and the device can not address its
+// LEDs individually an UnsupportedError is returned.
+// The FadeDuration must not be negative or longer than 0xFFFF*10ms.
+func (l *LED) SetPatternLine(pos int, line PatternLine) error {
+	return l.SetPatternLineContext(context.Background(), pos, line)
+}
+
+// SetPatternLineContext is like SetPatternLine but aborts when ctx is done.
+func (l *LED) SetPatternLineContext(ctx context.Context, pos int, line PatternLine) error {
+	if err := validateLineDuration(line.FadeDuration); err != nil {
+		return err
+	}
+
+	caps, err := l.CapabilitiesContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := validatePatternPosition(caps, pos); err != nil {
+		return err
+	}
+
+	cmd := &setPatternLineCommand{Color: line.Color, duration: line.FadeDuration, pos: byte(pos)}
+	if !caps.PerLEDAddressing {
+		if line.LEDIndex != 0 {
+			return &UnsupportedError{Op: "addressing individual LEDs", Generation: caps.Generation}
+		}
+
+		_, err = l.write(ctx, cmd)
+		return err
+	}
+
+	// the LED of a pattern line is set by a separate command right before the line is written
+	return l.writeAll(ctx, &setLEDCommand{n: line.LEDIndex}, cmd)
+}
+
+// ReadPatternLine reads the line at the given position of the color pattern table.
+func (l *LED) ReadPatternLine(pos int) (PatternLine, error) {
+	return l.ReadPatternLineContext(context.Background(), pos)
+}
+
+// ReadPatternLineContext is like ReadPatternLine but aborts when ctx is done.
+func (l *LED) ReadPatternLineContext(ctx context.Context, pos int) (PatternLine, error) {
+	caps, err := l.CapabilitiesContext(ctx)
+	if err != nil {
+		return PatternLine{}, err
+	}
+
+	if err := validatePatternPosition(caps, pos); err != nil {
+		return PatternLine{}, err
+	}
+
+	buf, err := l.read(ctx, &readPatternLineCommand{pos: byte(pos)})
+	if err != nil {
+		return PatternLine{}, err
+	}
+
+	line := PatternLine{
+		Color:        Color{R: buf[2], G: buf[3], B: buf[4]},
+		FadeDuration: time.Duration(int(buf[5])<<8|int(buf[6])) * 10 * time.Millisecond,
+	}
+
+	if caps.PerLEDAddressing {
+		line.LEDIndex = buf[7]
+	}
+
+	return line, nil
+}
+
+// SavePatterns saves the color pattern table to the flash memory of the device
+// so it survives a power cycle. Saving the pattern is supported by mk2 devices and later.
+func (l *LED) SavePatterns() error {
+	return l.SavePatternsContext(context.Background())
+}
+
+// SavePatternsContext is like SavePatterns but aborts when ctx is done.
+func (l *LED) SavePatternsContext(ctx context.Context) error {
+	if err := l.require(ctx, "saving the color pattern", func(c Capabilities) bool { return c.FlashSave }); err != nil {
+		return err
+	}
+
+	_, err := l.write(ctx, &savePatternsCommand{})
+	return err
+}
+
+func validatePatternPosition(caps Capabilities, pos int) error {
+	if pos < 0 || pos >= caps.PatternLines {
+		return fmt.Errorf("pattern position %d is out of range [0, %d]", pos, caps.PatternLines-1)
+	}
+
+	return nil
+}
+
+// validateLineDuration checks that d can be stored as the fade duration of a pattern line.
+func validateLineDuration(d time.Duration) error {
+	if d < 0 || d > maxLineDuration {
+		return fmt.Errorf("pattern line duration %s is out of range [0, %s]", d, maxLineDuration)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package blink
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var mk1Version = []byte{0x01, 'v', 0x00, '1', '5', 0x00, 0x00, 0x00}
+
+func TestPlayPattern(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{'v': mk2Version}}
+	led := NewLED(tr)
+
+	require.NoError(t, led.PlayPattern(3))
+	require.NoError(t, led.PlayPatternLoop(2, 5, 10))
+	require.NoError(t, led.PausePattern())
+	assert.Equal(t, [][]byte{
+		{0x01, 'p', 0x01, 0x03, 0x00, 0x00, 0x00, 0x00},
+		{0x01, 'p', 0x01, 0x02, 0x05, 0x0a, 0x00, 0x00},
+		{0x01, 'p', 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	}, tr.written[1:])
+
+	assert.Error(t, led.PlayPattern(32))
+	assert.Error(t, led.PlayPatternLoop(5, 2, 0))
+	assert.Error(t, led.PlayPatternLoop(0, 2, 256))
+}
+
+func TestPlayState(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{
+		'v': mk2Version,
+		'S': {0x01, 'S', 0x01, 0x02, 0x05, 0x03, 0x04, 0x00},
+	}}
+
+	state, err := NewLED(tr).PlayState()
+	require.NoError(t, err)
+	assert.Equal(t, PlayState{Playing: true, Start: 2, End: 5, Count: 3, Position: 4}, state)
+}
+
+func TestPlaybackOnMK1(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{'v': mk1Version}}
+	led := NewLED(tr)
+
+	assert.NoError(t, led.PlayPattern(11))
+	assert.Error(t, led.PlayPattern(12))
+
+	err := led.PlayPatternLoop(0, 5, 0)
+	assert.True(t, errors.Is(err, ErrUnsupported))
+
+	_, err = led.PlayState()
+	assert.True(t, errors.Is(err, ErrUnsupported))
+}
+
+func TestSetPatternLine(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{'v': mk2Version}}
+	led := NewLED(tr)
+
+	err := led.SetPatternLine(4, PatternLine{Color: Red, FadeDuration: 500 * time.Millisecond, LEDIndex: 2})
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{
+		{0x01, 'l', 0x02, 0x00, 0x00, 0x00, 0x00, 0x00},
+		{0x01, 'P', 0xff, 0x00, 0x00, 0x00, 0x32, 0x04},
+	}, tr.written[1:])
+
+	assert.Error(t, led.SetPatternLine(32, PatternLine{}))
+	assert.Error(t, led.SetPatternLine(-1, PatternLine{}))
+	assert.Error(t, led.SetPatternLine(0, PatternLine{FadeDuration: -time.Millisecond}))
+	assert.Error(t, led.SetPatternLine(0, PatternLine{FadeDuration: maxLineDuration + 10*time.Millisecond}))
+	assert.Len(t, tr.written, 3)
+}
+
+func TestSetPatternLineOnMK1(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{'v': mk1Version}}
+	led := NewLED(tr)
+
+	require.NoError(t, led.SetPatternLine(11, PatternLine{Color: Blue}))
+	assert.Equal(t, [][]byte{
+		{0x01, 'P', 0x00, 0x00, 0xff, 0x00, 0x00, 0x0b},
+	}, tr.written[1:])
+
+	err := led.SetPatternLine(0, PatternLine{Color: Blue, LEDIndex: 1})
+	assert.True(t, errors.Is(err, ErrUnsupported))
+
+	err = led.SavePatterns()
+	assert.True(t, errors.Is(err, ErrUnsupported))
+}
+
+func TestReadPatternLine(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{
+		'v': mk2Version,
+		'R': {0x01, 'R', 0x11, 0x22, 0x33, 0x01, 0x2c, 0x01},
+	}}
+
+	line, err := NewLED(tr).ReadPatternLine(3)
+	require.NoError(t, err)
+	assert.Equal(t, PatternLine{Color: Color{0x11, 0x22, 0x33}, FadeDuration: 3 * time.Second, LEDIndex: 1}, line)
+	assert.Equal(t, []byte{0x01, 'R', 0x00, 0x00, 0x00, 0x00, 0x00, 0x03}, tr.written[1])
+}
+
+func TestSavePatterns(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{'v': mk2Version}}
+
+	require.NoError(t, NewLED(tr).SavePatterns())
+	assert.Equal(t, []byte{0x01, 'W', 0xBE, 0xEF, 0xCA, 0xFE, 0x00, 0x00}, tr.written[1])
+}
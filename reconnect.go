@@ -0,0 +1,268 @@
+package blink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// reconnectInterval is the time between two attempts to reopen a disconnected device.
+var reconnectInterval = 250 * time.Millisecond
+
+var errTransportClosed = errors.New("transport has been closed")
+
+// A reconnectingTransport wraps the Transport of a USB device and transparently
+// reopens the device if it has been unplugged and plugged in again.
+// After the device was reopened the last color of each LED is restored before
+// any further reports are sent. Calls block while the device is disconnected.
+type reconnectingTransport struct {
+	info    DeviceInfo    // the device to reconnect to
+	maxWait time.Duration // how long to wait for the device to come back (0 means forever)
+
+	list func() ([]DeviceInfo, error)
+	open func(DeviceInfo) (Transport, error)
+
+	mu      sync.Mutex
+	current Transport       // nil while the device is disconnected
+	request []byte          // the last report that was written
+	state   map[byte][]byte // the last report that changed the color of each LED, 0 for all LEDs
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func newReconnectingTransport(di DeviceInfo, t Transport, maxWait time.Duration) *reconnectingTransport {
+	return &reconnectingTransport{
+		info:    di,
+		maxWait: maxWait,
+		list:    List,
+		open: func(di DeviceInfo) (Transport, error) {
+			dev, err := di.open()
+			if err != nil {
+				return nil, err
+			}
+			return dev, nil
+		},
+		current: t,
+		done:    make(chan struct{}),
+	}
+}
+
+// WriteReport implements Transport.
+func (t *reconnectingTransport) WriteReport(ctx context.Context, report []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.request = append(t.request[:0], report...)
+
+	for {
+		if err := t.connect(ctx, report); err != nil {
+			return err
+		}
+
+		err := t.current.WriteReport(ctx, report)
+		if isDisconnected(err) {
+			t.disconnect()
+			continue
+		}
+
+		if err == nil && isColorReport(report) {
+			t.remember(report)
+		}
+
+		return err
+	}
+}
+
+// ReadReport implements Transport. If the device was disconnected before the
+// report could be read, the last written report is sent again after the device
+// has been reopened so the device can prepare the response.
+func (t *reconnectingTransport) ReadReport(ctx context.Context, report []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for {
+		if t.current == nil {
+			if err := t.connect(ctx, nil); err != nil {
+				return err
+			}
+
+			if len(t.request) > 0 {
+				err := t.current.WriteReport(ctx, t.request)
+				if isDisconnected(err) {
+					t.disconnect()
+					continue
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		err := t.current.ReadReport(ctx, report)
+		if !isDisconnected(err) {
+			return err
+		}
+
+		t.disconnect()
+	}
+}
+
+// interrupt aborts any pending reconnect.
+func (t *reconnectingTransport) interrupt() {
+	t.closeOnce.Do(func() { close(t.done) })
+}
+
+// Close implements Transport by closing the underlying device and aborting any pending reconnect.
+func (t *reconnectingTransport) Close() error {
+	t.interrupt()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.current == nil {
+		return nil
+	}
+
+	err := t.current.Close()
+	t.current = nil
+	return err
+}
+
+func (t *reconnectingTransport) disconnect() {
+	t.current.Close()
+	t.current = nil
+}
+
+// connect reopens the device if it is currently disconnected and restores the
+// last colors which are not replaced by the next report anyway.
+// It blocks until the device is available again, maxWait has passed, ctx is done or the transport was closed.
+func (t *reconnectingTransport) connect(ctx context.Context, next []byte) error {
+	var deadline <-chan time.Time
+	if t.maxWait > 0 {
+		timer := time.NewTimer(t.maxWait)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	for t.current == nil {
+		select {
+		case <-t.done:
+			return errTransportClosed
+		default:
+		}
+
+		err := t.reopen(ctx, next)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-t.done:
+			return errTransportClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return fmt.Errorf("could not reconnect to blink1 device %s within %s: %w", t.info, t.maxWait, err)
+		case <-time.After(reconnectInterval):
+		}
+	}
+
+	return nil
+}
+
+func (t *reconnectingTransport) reopen(ctx context.Context, next []byte) error {
+	devices, err := t.list()
+	if err != nil {
+		return err
+	}
+
+	di, err := t.find(devices)
+	if err != nil {
+		return err
+	}
+
+	dev, err := t.open(di)
+	if err != nil {
+		return err
+	}
+
+	for _, report := range t.restore(next) {
+		if err := dev.WriteReport(ctx, report); err != nil {
+			dev.Close()
+			return err
+		}
+	}
+
+	t.current = dev
+	return nil
+}
+
+// remember stores the given color report as the last color of the LED it addresses.
+// A report which addresses all LEDs replaces the colors of the individual LEDs.
+func (t *reconnectingTransport) remember(report []byte) {
+	n := reportLEDIndex(report)
+	if n == 0 || t.state == nil {
+		t.state = map[byte][]byte{}
+	}
+
+	t.state[n] = append([]byte(nil), report...)
+}
+
+// restore returns the reports which restore the last colors of all LEDs in
+// the order in which they must be sent. Colors which are replaced by the next
+// report are omitted.
+func (t *reconnectingTransport) restore(next []byte) [][]byte {
+	replaced := -1
+	if isColorReport(next) {
+		replaced = int(reportLEDIndex(next))
+	}
+
+	if replaced == 0 {
+		return nil
+	}
+
+	var reports [][]byte
+	for n := 0; n <= 0xff; n++ {
+		if report, ok := t.state[byte(n)]; ok && n != replaced {
+			reports = append(reports, report)
+		}
+	}
+
+	return reports
+}
+
+// find looks up the device by its serial number or by its path if it has no serial number.
+func (t *reconnectingTransport) find(devices []DeviceInfo) (DeviceInfo, error) {
+	for _, di := range devices {
+		if t.info.Serial != "" && di.Serial == t.info.Serial {
+			return di, nil
+		}
+		if t.info.Serial == "" && di.Path == t.info.Path {
+			return di, nil
+		}
+	}
+
+	return DeviceInfo{}, ErrNoDevice
+}
+
+// isDisconnected returns true if err signals that the device has been unplugged.
+func isDisconnected(err error) bool {
+	return errors.Is(err, ErrDisconnected)
+}
+
+// isColorReport returns true if the given report changes the color of the device.
+func isColorReport(report []byte) bool {
+	return len(report) > 1 && report[0] == reportID && (report[1] == 'n' || report[1] == 'c')
+}
+
+// reportLEDIndex returns the index of the LED which is addressed by the given color report: 0=all, 1=led#1, 2=led#2, etc.
+func reportLEDIndex(report []byte) byte {
+	if report[1] == 'c' && len(report) > 7 {
+		return report[7]
+	}
+
+	return 0
+}
@@ -0,0 +1,165 @@
+package blink
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unpluggableTransport is a fakeTransport that fails with ErrDisconnected once it has been unplugged.
+type unpluggableTransport struct {
+	fakeTransport
+	unplugged bool
+}
+
+func (t *unpluggableTransport) WriteReport(ctx context.Context, report []byte) error {
+	if t.unplugged {
+		return ErrDisconnected
+	}
+	return t.fakeTransport.WriteReport(ctx, report)
+}
+
+func (t *unpluggableTransport) ReadReport(ctx context.Context, report []byte) error {
+	if t.unplugged {
+		return ErrDisconnected
+	}
+	return t.fakeTransport.ReadReport(ctx, report)
+}
+
+func TestReconnectRestoresLastColor(t *testing.T) {
+	defer func(d time.Duration) { reconnectInterval = d }(reconnectInterval)
+	reconnectInterval = time.Millisecond
+
+	di := DeviceInfo{Path: "27b8:01ed:01", Serial: "2000ABCD"}
+	first := &unpluggableTransport{}
+	second := &unpluggableTransport{fakeTransport: fakeTransport{responses: map[byte][]byte{'v': mk2Version}}}
+
+	attempts := 0
+	tr := newReconnectingTransport(di, first, 0)
+	tr.list = func() ([]DeviceInfo, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, nil // device is still unplugged
+		}
+		// the device comes back on another port
+		return []DeviceInfo{{Path: "27b8:01ed:02", Serial: "2000ABCD"}}, nil
+	}
+	tr.open = func(di DeviceInfo) (Transport, error) {
+		assert.Equal(t, "27b8:01ed:02", di.Path)
+		return second, nil
+	}
+
+	led := NewLED(tr)
+	require.NoError(t, led.Fade(Red, 100*time.Millisecond))
+
+	first.unplugged = true
+	_, err := led.Version()
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	assert.True(t, first.closed)
+	assert.Equal(t, [][]byte{
+		{0x01, 'c', 0xff, 0x00, 0x00, 0x00, 0x0a, 0x00}, // restored
+		{0x01, 'v', 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	}, second.written)
+}
+
+func TestReconnectDoesNotRestoreReplacedColor(t *testing.T) {
+	defer func(d time.Duration) { reconnectInterval = d }(reconnectInterval)
+	reconnectInterval = time.Millisecond
+
+	di := DeviceInfo{Path: "27b8:01ed:01"}
+	first := &unpluggableTransport{}
+	second := &unpluggableTransport{}
+
+	tr := newReconnectingTransport(di, first, 0)
+	tr.list = func() ([]DeviceInfo, error) { return []DeviceInfo{di}, nil }
+	tr.open = func(DeviceInfo) (Transport, error) { return second, nil }
+
+	led := NewLED(tr)
+	require.NoError(t, led.Set(Red))
+
+	first.unplugged = true
+	require.NoError(t, led.Set(Blue))
+	assert.Equal(t, [][]byte{{0x01, 'n', 0x00, 0x00, 0xff, 0x00, 0x00, 0x00}}, second.written)
+}
+
+func TestReconnectRestoresColorOfEachLED(t *testing.T) {
+	defer func(d time.Duration) { reconnectInterval = d }(reconnectInterval)
+	reconnectInterval = time.Millisecond
+
+	di := DeviceInfo{Path: "27b8:01ed:01"}
+	first := &unpluggableTransport{fakeTransport: fakeTransport{responses: map[byte][]byte{'v': mk2Version}}}
+	second := &unpluggableTransport{fakeTransport: fakeTransport{responses: map[byte][]byte{'v': mk2Version}}}
+
+	tr := newReconnectingTransport(di, first, 0)
+	tr.list = func() ([]DeviceInfo, error) { return []DeviceInfo{di}, nil }
+	tr.open = func(DeviceInfo) (Transport, error) { return second, nil }
+
+	led := NewLED(tr)
+	require.NoError(t, led.Set(Green))
+	require.NoError(t, led.SetEach(Red, Blue))
+
+	first.unplugged = true
+	_, err := led.Version()
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{
+		{0x01, 'n', 0x00, 0xff, 0x00, 0x00, 0x00, 0x00}, // the color of all LEDs is restored first
+		{0x01, 'c', 0xff, 0x00, 0x00, 0x00, 0x00, 0x01},
+		{0x01, 'c', 0x00, 0x00, 0xff, 0x00, 0x00, 0x02},
+		{0x01, 'v', 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	}, second.written)
+
+	// a new color for a single LED must not replace the restored color of the other one
+	second.written = nil
+	second.unplugged = true
+	third := &unpluggableTransport{}
+	tr.open = func(DeviceInfo) (Transport, error) { return third, nil }
+
+	require.NoError(t, led.Channel(2).Set(Yellow))
+	assert.Equal(t, [][]byte{
+		{0x01, 'n', 0x00, 0xff, 0x00, 0x00, 0x00, 0x00},
+		{0x01, 'c', 0xff, 0x00, 0x00, 0x00, 0x00, 0x01},
+		{0x01, 'c', 0xff, 0xff, 0x00, 0x00, 0x00, 0x02},
+	}, third.written)
+
+	// a color for all LEDs replaces the colors of the individual LEDs
+	require.NoError(t, led.Set(Green))
+	assert.Equal(t, map[byte][]byte{0: {0x01, 'n', 0x00, 0xff, 0x00, 0x00, 0x00, 0x00}}, tr.state)
+}
+
+func TestReconnectGivesUpAfterMaxWait(t *testing.T) {
+	defer func(d time.Duration) { reconnectInterval = d }(reconnectInterval)
+	reconnectInterval = time.Millisecond
+
+	tr := newReconnectingTransport(DeviceInfo{Serial: "2000ABCD"}, &unpluggableTransport{unplugged: true}, 10*time.Millisecond)
+	tr.list = func() ([]DeviceInfo, error) { return nil, nil }
+
+	err := NewLED(tr).Set(Red)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), ErrNoDevice.Error())
+}
+
+func TestReconnectStopsWhenClosed(t *testing.T) {
+	defer func(d time.Duration) { reconnectInterval = d }(reconnectInterval)
+	reconnectInterval = time.Millisecond
+
+	tr := newReconnectingTransport(DeviceInfo{Serial: "2000ABCD"}, &unpluggableTransport{unplugged: true}, 0)
+	tr.list = func() ([]DeviceInfo, error) { return nil, nil }
+
+	errs := make(chan error)
+	go func() { errs <- NewLED(tr).Set(Red) }()
+
+	time.Sleep(10 * time.Millisecond)
+	tr.closeOnce.Do(func() { close(tr.done) })
+
+	select {
+	case err := <-errs:
+		assert.Equal(t, errTransportClosed, err)
+	case <-time.After(time.Second):
+		t.Fatal("Set did not return after the transport was closed")
+	}
+}
@@ -0,0 +1,80 @@
+package blink
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// A RetryPolicy configures if and how an LED retries transfers that failed.
+// Each retry repeats the whole transfer, i.e. for reads the request is sent again.
+// The zero value disables retries.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts including the first one.
+	Attempts int
+
+	// Backoff is the time to wait before the first retry.
+	// It is doubled after each further attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration // zero means no limit
+
+	// Retryable reports whether a failed transfer should be retried.
+	// If it is nil, IsTransient is used.
+	Retryable func(error) bool
+
+	// OnAttempt is called after each attempt with its number (starting at 1)
+	// and the resulting error which is nil if the attempt succeeded.
+	// It can be used to log or count failed transfers.
+	OnAttempt func(attempt int, err error)
+}
+
+// DefaultRetryPolicy retries transient errors up to two times.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:   3,
+	Backoff:    10 * time.Millisecond,
+	MaxBackoff: 100 * time.Millisecond,
+}
+
+// IsTransient returns true if err is a timeout, a pipe error or signals that
+// the device is busy. Such errors usually go away if the transfer is retried.
+func IsTransient(err error) bool {
+	return errors.Is(err, ErrTimeout) || errors.Is(err, ErrPipe) || errors.Is(err, ErrBusy)
+}
+
+// WithRetry configures the LED to retry failed transfers according to the given policy.
+// Example:
+//     led, err := blink.New(blink.WithRetry(blink.DefaultRetryPolicy))
+func WithRetry(p RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = p
+	}
+}
+
+// do calls transfer until it succeeds or the policy does not allow any further attempts.
+func (p RetryPolicy) do(ctx context.Context, transfer func() error) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsTransient
+	}
+
+	backoff := p.Backoff
+	for attempt := 1; ; attempt++ {
+		err := transfer()
+		if p.OnAttempt != nil {
+			p.OnAttempt(attempt, err)
+		}
+
+		if err == nil || attempt >= p.Attempts || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		if err := sleep(ctx, backoff); err != nil {
+			return err
+		}
+
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
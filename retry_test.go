@@ -0,0 +1,98 @@
+package blink
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyTransport fails the first n transfers with err.
+type flakyTransport struct {
+	fakeTransport
+	n   int
+	err error
+}
+
+func (t *flakyTransport) WriteReport(ctx context.Context, report []byte) error {
+	if t.n > 0 {
+		t.n--
+		return t.err
+	}
+	return t.fakeTransport.WriteReport(ctx, report)
+}
+
+func TestRetryTransientErrors(t *testing.T) {
+	tr := &flakyTransport{n: 2, err: &DeviceError{Err: ErrPipe}}
+
+	var attempts []error
+	led := NewLED(tr, WithRetry(RetryPolicy{
+		Attempts: 3,
+		Backoff:  time.Millisecond,
+		OnAttempt: func(attempt int, err error) {
+			assert.Equal(t, len(attempts)+1, attempt)
+			attempts = append(attempts, err)
+		},
+	}))
+
+	assert.NoError(t, led.Set(Red))
+	assert.Equal(t, []error{tr.err, tr.err, nil}, attempts)
+	assert.Len(t, tr.written, 1)
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	tr := &flakyTransport{n: 5, err: &DeviceError{Err: ErrTimeout}}
+	led := NewLED(tr, WithRetry(RetryPolicy{Attempts: 3}))
+
+	assert.Equal(t, tr.err, led.Set(Red))
+	assert.Equal(t, 2, tr.n)
+}
+
+func TestRetryOnlyRetryableErrors(t *testing.T) {
+	tr := &flakyTransport{n: 5, err: &DeviceError{Err: ErrDisconnected}}
+	led := NewLED(tr, WithRetry(DefaultRetryPolicy))
+	assert.Equal(t, tr.err, led.Set(Red))
+	assert.Equal(t, 4, tr.n)
+
+	custom := errors.New("custom error")
+	tr = &flakyTransport{n: 1, err: custom}
+	led = NewLED(tr, WithRetry(RetryPolicy{
+		Attempts:  2,
+		Retryable: func(err error) bool { return err == custom },
+	}))
+	assert.NoError(t, led.Set(Red))
+}
+
+func TestRetryResendsReadRequest(t *testing.T) {
+	tr := &flakyTransport{fakeTransport: fakeTransport{responses: map[byte][]byte{'v': mk2Version}}}
+	led := NewLED(tr, WithRetry(RetryPolicy{Attempts: 2}))
+
+	_, err := led.Capabilities() // read and cache the capabilities so the next read only sends a single request
+	assert.NoError(t, err)
+
+	tr.n, tr.err = 1, &DeviceError{Err: ErrBusy}
+	tr.responses['r'] = []byte{0x01, 'r', 0x01, 0x02, 0x03, 0x00, 0x00, 0x00}
+	c, err := led.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, Color{1, 2, 3}, c)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	var delays []time.Duration
+	last := time.Now()
+
+	p := RetryPolicy{Attempts: 4, Backoff: 5 * time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+	p.do(context.Background(), func() error {
+		now := time.Now()
+		delays = append(delays, now.Sub(last))
+		last = now
+		return &DeviceError{Err: ErrTimeout}
+	})
+
+	assert.Len(t, delays, 4)
+	assert.True(t, delays[1] >= 5*time.Millisecond)
+	assert.True(t, delays[2] >= 10*time.Millisecond)
+	assert.True(t, delays[3] >= 10*time.Millisecond)
+}
@@ -0,0 +1,246 @@
+package blink
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// errClosed is returned when a command is sent to the write scheduler after the LED has been closed.
+var errClosed = errors.New("led is closed")
+
+// SchedulerStats contains counters of the write scheduler which is enabled via WithRateLimit.
+type SchedulerStats struct {
+	Sent    uint64 // the number of commands that have been sent to the device
+	Merged  uint64 // the number of commands that were replaced by a newer command for the same LED before they were sent
+	Dropped uint64 // the number of commands for a single LED that were superseded by a newer command for all LEDs
+	Failed  uint64 // the number of commands that could not be sent
+
+	LastError error // the error of the last command that could not be sent
+}
+
+// WithRateLimit enables a write scheduler which sends at most one Set or Fade
+// command per interval to the device. This is useful if the LED is updated
+// from high frequency sources which would otherwise flood the device.
+//
+// Set and Fade only queue their command and return immediately. If a queued
+// command is superseded by a newer one before it was sent, the older one is
+// discarded, so the device always ends up in the latest requested state.
+// The commands of SetEach and FadeEach are queued and sent together.
+// Errors of queued commands are not returned by Set or Fade but are reported
+// via LED.SchedulerStats.
+//
+// All other methods, e.g. Read, PlayPattern and Close, wait until all queued
+// commands have been sent, so the device receives all commands in the order
+// in which they were issued. Close waits at most USBTimeOut for the queued
+// commands, e.g. if the device has been unplugged and is not reconnected in
+// time.
+func WithRateLimit(interval time.Duration) Option {
+	return func(o *options) {
+		o.rateLimit = interval
+	}
+}
+
+// scheduler queues color commands and sends them with a limited rate.
+// Commands are queued in units which are sent at once. A unit is discarded
+// if a newer unit addresses all of its LEDs.
+type scheduler struct {
+	conn     *conn
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending [][]command
+	stats   SchedulerStats
+	closed  bool // no more commands are accepted
+	sending chan struct{} // held while queued commands are sent
+
+	ctx      context.Context // used for all writes, cancelled when stopping takes too long
+	cancel   context.CancelFunc
+	wake     chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+func newScheduler(c *conn, interval time.Duration) *scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &scheduler{
+		conn:     c,
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+		sending:  make(chan struct{}, 1),
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+
+	go s.loop()
+	return s
+}
+
+// submit queues the given set or fade commands as one unit and discards all queued units it supersedes.
+// An error is returned if the scheduler has been stopped.
+func (s *scheduler) submit(cmds ...command) error {
+	leds := map[byte]bool{}
+	for _, cmd := range cmds {
+		leds[ledIndex(cmd)] = true
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return errClosed
+	}
+
+	queued := s.pending[:0]
+	for _, p := range s.pending {
+		switch {
+		case addressesAll(leds, p):
+			s.stats.Merged += uint64(len(p))
+		case leds[0]:
+			s.stats.Dropped += uint64(len(p))
+		default:
+			queued = append(queued, p)
+		}
+	}
+	s.pending = append(queued, cmds)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// addressesAll returns true if leds contains the LED index of each of the given commands.
+func addressesAll(leds map[byte]bool, cmds []command) bool {
+	for _, cmd := range cmds {
+		if !leds[ledIndex(cmd)] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *scheduler) loop() {
+	defer close(s.stopped)
+
+	var last time.Time
+	for {
+		select {
+		case <-s.wake:
+		case <-s.done:
+			return
+		}
+
+		for s.queued() {
+			if wait := s.interval - time.Since(last); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-s.done:
+					return
+				}
+			}
+
+			last = time.Now()
+			s.send(s.ctx, 1)
+		}
+	}
+}
+
+func (s *scheduler) queued() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending) > 0
+}
+
+// send sends up to max queued units to the device. If max is negative all queued units are sent.
+// It returns when the commands have been sent, even if they have been taken from the queue by a concurrent call.
+// An error is returned only if ctx is done before the commands could be taken from the queue.
+func (s *scheduler) send(ctx context.Context, max int) error {
+	select {
+	case s.sending <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-s.sending }()
+
+	s.mu.Lock()
+	if max < 0 || max > len(s.pending) {
+		max = len(s.pending)
+	}
+	units := append([][]command(nil), s.pending[:max]...)
+	s.pending = s.pending[max:]
+	s.mu.Unlock()
+
+	for _, cmds := range units {
+		err := s.conn.sendAll(ctx, cmds...)
+
+		s.mu.Lock()
+		if err != nil {
+			s.stats.Failed += uint64(len(cmds))
+			s.stats.LastError = err
+		} else {
+			s.stats.Sent += uint64(len(cmds))
+		}
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+// flush immediately sends all queued commands.
+// Errors of the commands are recorded in the stats.
+func (s *scheduler) flush(ctx context.Context) error {
+	return s.send(ctx, -1)
+}
+
+// stop terminates the scheduler after all queued commands have been sent.
+// Writes which have not completed after USBTimeOut are aborted, so stop
+// does not block forever if a write waits for a disconnected device.
+// It is safe to call stop multiple times.
+func (s *scheduler) stop() {
+	s.stopOnce.Do(func() {
+		timer := time.AfterFunc(USBTimeOut, s.cancel)
+		defer timer.Stop()
+		defer s.cancel()
+
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+
+		close(s.done)
+		<-s.stopped
+		s.flush(s.ctx)
+	})
+}
+
+func (s *scheduler) snapshot() SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// ledIndex returns the index of the LED which is addressed by the given set or fade command.
+func ledIndex(cmd command) byte {
+	if c, ok := cmd.(*fadeRGBCommand); ok {
+		return c.n
+	}
+
+	return 0
+}
+
+// SchedulerStats returns the counters of the write scheduler or zero values
+// if the scheduler has not been enabled via WithRateLimit.
+func (l *LED) SchedulerStats() SchedulerStats {
+	if l.scheduler == nil {
+		return SchedulerStats{}
+	}
+
+	return l.scheduler.snapshot()
+}
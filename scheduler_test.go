@@ -0,0 +1,172 @@
+package blink
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncTransport is a fakeTransport that can be used concurrently.
+type syncTransport struct {
+	mu sync.Mutex
+	fakeTransport
+}
+
+func (t *syncTransport) WriteReport(ctx context.Context, report []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.fakeTransport.WriteReport(ctx, report)
+}
+
+func (t *syncTransport) ReadReport(ctx context.Context, report []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.fakeTransport.ReadReport(ctx, report)
+}
+
+func (t *syncTransport) reports() [][]byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([][]byte(nil), t.written...)
+}
+
+func TestRateLimitMergesSupersededCommands(t *testing.T) {
+	tr := &syncTransport{}
+	led := NewLED(tr, WithRateLimit(time.Hour))
+
+	// the first command is sent immediately
+	require.NoError(t, led.Set(Color{R: 1}))
+	assert.Eventually(t, func() bool { return len(tr.reports()) == 1 }, time.Second, time.Millisecond)
+
+	// all following commands must wait an hour and replace each other
+	for i := byte(2); i <= 10; i++ {
+		require.NoError(t, led.Set(Color{R: i}))
+	}
+
+	assert.Len(t, tr.reports(), 1)
+	assert.NoError(t, led.Close())
+	assert.Equal(t, [][]byte{
+		{0x01, 'n', 0x01, 0x00, 0x00, 0x00, 0x00, 0x00},
+		{0x01, 'n', 0x0a, 0x00, 0x00, 0x00, 0x00, 0x00},
+	}, tr.reports())
+
+	assert.Equal(t, SchedulerStats{Sent: 2, Merged: 8}, led.SchedulerStats())
+}
+
+func TestRateLimitKeepsCommandsForDifferentLEDs(t *testing.T) {
+	tr := &syncTransport{fakeTransport: fakeTransport{responses: map[byte][]byte{'v': mk2Version}}}
+	led := NewLED(tr, WithRateLimit(time.Hour))
+	_, err := led.Capabilities()
+	require.NoError(t, err)
+
+	require.NoError(t, led.Set(Red)) // sent immediately
+	assert.Eventually(t, func() bool { return len(tr.reports()) == 2 }, time.Second, time.Millisecond)
+
+	led.ID = 1
+	require.NoError(t, led.Fade(Green, 0))
+	led.ID = 2
+	require.NoError(t, led.Fade(Blue, 0))
+	require.NoError(t, led.Fade(Yellow, 0))
+
+	assert.Equal(t, SchedulerStats{Sent: 1, Merged: 1}, led.SchedulerStats())
+
+	led.ID = 0
+	require.NoError(t, led.Fade(White, 0))
+	assert.Equal(t, SchedulerStats{Sent: 1, Merged: 1, Dropped: 2}, led.SchedulerStats())
+}
+
+func TestRateLimitFlushesBeforeRead(t *testing.T) {
+	tr := &syncTransport{fakeTransport: fakeTransport{responses: map[byte][]byte{'v': mk2Version}}}
+	led := NewLED(tr, WithRateLimit(time.Hour))
+	_, err := led.Capabilities()
+	require.NoError(t, err)
+
+	require.NoError(t, led.Set(Red))
+	require.NoError(t, led.Set(Green))
+	_, err = led.Read()
+	require.NoError(t, err)
+
+	reports := tr.reports()
+	assert.Equal(t, []byte{0x01, 'n', 0x00, 0xff, 0x00, 0x00, 0x00, 0x00}, reports[len(reports)-2])
+	assert.Equal(t, byte('r'), reports[len(reports)-1][1])
+}
+
+func TestRateLimitCloseDoesNotWaitForDisconnectedDevice(t *testing.T) {
+	defer func(d, timeout time.Duration) { reconnectInterval, USBTimeOut = d, timeout }(reconnectInterval, USBTimeOut)
+	reconnectInterval, USBTimeOut = time.Millisecond, 10*time.Millisecond
+
+	tr := newReconnectingTransport(DeviceInfo{Serial: "2000ABCD"}, &unpluggableTransport{unplugged: true}, 0)
+	tr.list = func() ([]DeviceInfo, error) { return nil, nil }
+	led := NewLED(tr, WithRateLimit(time.Hour))
+
+	require.NoError(t, led.Set(Red))
+	require.NoError(t, led.Set(Green))
+
+	closed := make(chan error)
+	go func() { closed <- led.Close() }()
+
+	select {
+	case err := <-closed:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return while the device was disconnected")
+	}
+
+	assert.NotZero(t, led.SchedulerStats().Failed)
+}
+
+func TestRateLimitSendsCommandsInOrder(t *testing.T) {
+	tr := &syncTransport{fakeTransport: fakeTransport{responses: map[byte][]byte{'v': mk2Version}}}
+	led := NewLED(tr, WithRateLimit(time.Hour))
+	_, err := led.Capabilities()
+	require.NoError(t, err)
+
+	require.NoError(t, led.Set(Red)) // sent immediately
+	assert.Eventually(t, func() bool { return len(tr.reports()) == 2 }, time.Second, time.Millisecond)
+
+	require.NoError(t, led.Set(Blue))
+	require.NoError(t, led.PlayPattern(0))
+	assert.Equal(t, []byte{0x01, 'p', 0x01, 0x00, 0x00, 0x00, 0x00, 0x00}, tr.reports()[3])
+
+	require.NoError(t, led.Set(Green))
+	require.NoError(t, led.Close())
+
+	var ops []byte
+	for _, r := range tr.reports() {
+		ops = append(ops, r[1])
+	}
+	assert.Equal(t, []byte("vnnpn"), ops)
+	assert.Equal(t, []byte{0x01, 'n', 0x00, 0x00, 0xff, 0x00, 0x00, 0x00}, tr.reports()[2])
+}
+
+func TestRateLimitSendsEachLEDTogether(t *testing.T) {
+	tr := &syncTransport{fakeTransport: fakeTransport{responses: map[byte][]byte{'v': mk2Version}}}
+	led := NewLED(tr, WithRateLimit(time.Hour))
+
+	// both LEDs are updated immediately instead of one interval apart
+	require.NoError(t, led.SetEach(Red, Blue))
+	assert.Eventually(t, func() bool { return len(tr.reports()) == 3 }, time.Second, time.Millisecond)
+
+	// a unit is only discarded if a newer unit addresses all of its LEDs
+	require.NoError(t, led.FadeEach(time.Second, Green, Green))
+	require.NoError(t, led.Channel(1).Set(Yellow))
+	require.NoError(t, led.SetEach(White, White))
+	assert.Equal(t, SchedulerStats{Sent: 2, Merged: 3}, led.SchedulerStats())
+
+	require.NoError(t, led.Close())
+	assert.Equal(t, SchedulerStats{Sent: 4, Merged: 3}, led.SchedulerStats())
+}
+
+func TestRateLimitRejectsCommandsAfterClose(t *testing.T) {
+	tr := &syncTransport{fakeTransport: fakeTransport{responses: map[byte][]byte{'v': mk2Version}}}
+	led := NewLED(tr, WithRateLimit(time.Hour))
+	require.NoError(t, led.Close())
+
+	assert.EqualError(t, led.Set(Red), "led is closed")
+	assert.EqualError(t, led.Fade(Red, time.Second), "led is closed")
+	assert.Empty(t, tr.reports())
+}
@@ -1,6 +1,7 @@
 package blink
 
 import (
+	"context"
 	"fmt"
 	"time"
 )
@@ -16,7 +17,7 @@ type Sequence struct {
 }
 
 type frame interface {
-	run(*LED) error
+	run(context.Context, Device) error
 }
 
 // NewSequence creates a new sequence and can be used to chain multiple sequence instructions
@@ -61,6 +62,37 @@ func (s *Sequence) Fade(c Color, d time.Duration) *Sequence {
 	return s
 }
 
+// SetLED adds a new frame to the sequence which immediately sets the LED with
+// index n to another color and waits a given duration (mk2 only).
+// Frames added via Set and Fade address the LED the sequence is played on.
+// The frame is played on the Channel n of the device, so the sequence can
+// only be played on an LED or a Group.
+// Example:
+//     s := blink.NewSequence().
+//         SetLED(1, blink.Red, 0).
+//         SetLED(2, blink.Blue, 500*time.Millisecond)
+func (s *Sequence) SetLED(n byte, c Color, d time.Duration) *Sequence {
+	s.frames = append(s.frames, &cmdFrame{
+		command:  &setRGBCommand{Color: c},
+		Duration: d,
+		led:      n,
+	})
+
+	return s
+}
+
+// FadeLED adds a new frame to the sequence which lets the LED with index n
+// fade to another color (mk2 only).
+func (s *Sequence) FadeLED(n byte, c Color, d time.Duration) *Sequence {
+	s.frames = append(s.frames, &cmdFrame{
+		command:  &fadeRGBCommand{Color: c, duration: d},
+		Duration: d,
+		led:      n,
+	})
+
+	return s
+}
+
 // Wait adds a new frame to the sequence which doesn't do anything for a given duration.
 func (s *Sequence) Wait(d time.Duration) *Sequence {
 	s.frames = append(s.frames, &waitFrame{d})
@@ -110,22 +142,40 @@ func (s *Sequence) Start() *Sequence {
 func (s *Sequence) FadeFunc(f func() Color, d time.Duration) *Sequence {
 	s.frames = append(s.frames, &fadeFuncFrame{
 		Duration: d,
-		fun: func() command {
-			return &fadeRGBCommand{Color: f(), duration: d}
-		},
+		fun:      f,
 	})
 
 	return s
 }
 
-// Play starts to playback this sequence on the given LED.
+// Play starts to playback this sequence on the given device, e.g. an LED or a Group.
 // It blocks until all frames have been processed.
 // If this sequence loops Play will never return by itself.
-func (s *Sequence) Play(led *LED) error {
-	if led == nil {
-		return fmt.Errorf("led is nil")
+func (s *Sequence) Play(d Device) error {
+	return s.PlayContext(context.Background(), d)
+}
+
+// PlayContext is like Play but stops the playback as soon as ctx is done.
+// In this case the error of ctx is returned.
+// If d is a ContextDevice, ctx is also used for each call to the device.
+func (s *Sequence) PlayContext(ctx context.Context, d Device) error {
+	switch d := d.(type) {
+	case nil:
+		return fmt.Errorf("device is nil")
+	case *LED:
+		if d == nil {
+			return fmt.Errorf("led is nil")
+		}
+	case *Group:
+		if d == nil {
+			return fmt.Errorf("group is nil")
+		}
 	}
 
+	return s.play(ctx, d)
+}
+
+func (s *Sequence) play(ctx context.Context, d Device) error {
 	s.i = 0
 	var err error
 	for {
@@ -134,7 +184,7 @@ func (s *Sequence) Play(led *LED) error {
 		}
 
 		f := s.frames[s.i]
-		if err = f.run(led); err != nil {
+		if err = f.run(ctx, d); err != nil {
 			return err
 		}
 
@@ -147,23 +197,38 @@ func (s *Sequence) Play(led *LED) error {
 type cmdFrame struct {
 	command
 	time.Duration
+	led byte // which LED to address: 0=the device the sequence is played on, 1=led#1, 2=led#2, etc.
 }
 
-func (f *cmdFrame) run(led *LED) error {
-	_, err := led.write(f.command)
+func (f *cmdFrame) run(ctx context.Context, d Device) error {
+	if f.led != 0 {
+		var err error
+		if d, err = channel(d, f.led); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch cmd := f.command.(type) {
+	case *setRGBCommand:
+		err = set(ctx, d, cmd.Color)
+	case *fadeRGBCommand:
+		err = fade(ctx, d, cmd.Color, cmd.duration)
+	default:
+		err = fmt.Errorf("unsupported command %T", cmd)
+	}
+
 	if err != nil {
 		return err
 	}
 
-	time.Sleep(f.Duration)
-	return nil
+	return sleep(ctx, f.Duration)
 }
 
 type waitFrame struct{ time.Duration }
 
-func (f *waitFrame) run(led *LED) error {
-	time.Sleep(f.Duration)
-	return nil
+func (f *waitFrame) run(ctx context.Context, d Device) error {
+	return sleep(ctx, f.Duration)
 }
 
 type loopFrame struct {
@@ -171,7 +236,7 @@ type loopFrame struct {
 	n   int
 }
 
-func (f *loopFrame) run(led *LED) error {
+func (f *loopFrame) run(ctx context.Context, d Device) error {
 	if f.n > 0 {
 		f.n--
 	}
@@ -188,7 +253,7 @@ type startFrame struct {
 	n   int
 }
 
-func (f *startFrame) run(led *LED) error {
+func (f *startFrame) run(ctx context.Context, d Device) error {
 	f.seq.frames = f.seq.frames[f.n+1:]
 	f.seq.i = 0
 	return nil
@@ -196,15 +261,57 @@ func (f *startFrame) run(led *LED) error {
 
 type fadeFuncFrame struct {
 	time.Duration
-	fun func() command
+	fun func() Color
 }
 
-func (f *fadeFuncFrame) run(led *LED) error {
-	_, err := led.write(f.fun())
+func (f *fadeFuncFrame) run(ctx context.Context, d Device) error {
+	err := fade(ctx, d, f.fun(), f.Duration)
 	if err != nil {
 		return err
 	}
 
-	time.Sleep(f.Duration)
-	return nil
+	return sleep(ctx, f.Duration)
+}
+
+// set sets the color of d using ctx if d is a ContextDevice.
+func set(ctx context.Context, d Device, c Color) error {
+	if d, ok := d.(ContextDevice); ok {
+		return d.SetContext(ctx, c)
+	}
+
+	return d.Set(c)
+}
+
+// fade lets d fade to the color c using ctx if d is a ContextDevice.
+func fade(ctx context.Context, d Device, c Color, duration time.Duration) error {
+	if d, ok := d.(ContextDevice); ok {
+		return d.FadeContext(ctx, c, duration)
+	}
+
+	return d.Fade(c, duration)
+}
+
+// channel returns the device that addresses the LED with index n of d.
+func channel(d Device, n byte) (Device, error) {
+	switch d := d.(type) {
+	case *LED:
+		return d.Channel(n), nil
+	case *Group:
+		return d.Channel(n), nil
+	default:
+		return nil, fmt.Errorf("can not address individual LEDs of %T", d)
+	}
+}
+
+// sleep pauses the current goroutine for at least the duration d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
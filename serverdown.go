@@ -0,0 +1,141 @@
+package blink
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// The range of serverdown timeouts which are supported by the device.
+// The timeout is sent in steps of 10ms as a 16 bit number.
+const (
+	MinServerdownTimeout = 10 * time.Millisecond
+	MaxServerdownTimeout = 0xFFFF * 10 * time.Millisecond
+)
+
+// ServerdownConfig configures the hardware watchdog ("serverdown" mode) of a blink(1).
+// Once the watchdog is armed the device starts to play its color pattern if it
+// does not receive another tickle within the timeout. This way the device can
+// signal that the process which controls it has died.
+type ServerdownConfig struct {
+	Timeout time.Duration // the time without tickle after which the pattern starts to play (see MinServerdownTimeout and MaxServerdownTimeout)
+
+	// Start and End select the range of pattern lines to play.
+	// Selecting a range is supported by mk2 devices and later. If both are zero
+	// the whole pattern is played.
+	Start, End int
+}
+
+// Tickle arms the watchdog or resets its timer if it is already armed.
+// Call Tickle again within the configured timeout to keep the pattern from playing.
+func (l *LED) Tickle(cfg ServerdownConfig) error {
+	return l.TickleContext(context.Background(), cfg)
+}
+
+// TickleContext is like Tickle but aborts when ctx is done.
+func (l *LED) TickleContext(ctx context.Context, cfg ServerdownConfig) error {
+	if err := validateServerdownTimeout(cfg.Timeout); err != nil {
+		return err
+	}
+
+	if cfg.Start != 0 || cfg.End != 0 {
+		caps, err := l.CapabilitiesContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		if caps.Generation < MK2 {
+			return &UnsupportedError{Op: "selecting the serverdown pattern range", Generation: caps.Generation}
+		}
+
+		if err := validatePatternRange(caps, cfg.Start, cfg.End); err != nil {
+			return err
+		}
+	}
+
+	_, err := l.write(ctx, &serverdownCommand{
+		on:      true,
+		timeout: cfg.Timeout,
+		start:   byte(cfg.Start),
+		end:     byte(cfg.End),
+	})
+
+	return err
+}
+
+// DisarmServerdown disarms the watchdog. If stayLit is true the current color is
+// kept, otherwise the LED is turned off (mk2 devices and later).
+func (l *LED) DisarmServerdown(stayLit bool) error {
+	return l.DisarmServerdownContext(context.Background(), stayLit)
+}
+
+// DisarmServerdownContext is like DisarmServerdown but aborts when ctx is done.
+func (l *LED) DisarmServerdownContext(ctx context.Context, stayLit bool) error {
+	_, err := l.write(ctx, &serverdownCommand{on: false, stayLit: stayLit})
+	return err
+}
+
+// Keepalive arms the watchdog with the given timeout and keeps tickling it
+// until ctx is done. Afterwards the watchdog is disarmed while the current
+// color is kept. If the process dies without disarming the watchdog, the
+// device starts to play its color pattern once the timeout has passed.
+//
+// Keepalive blocks until ctx is done and should be run in its own goroutine.
+// It returns nil if the watchdog was disarmed successfully or the first error
+// that occurred otherwise.
+// Example:
+//     ctx, cancel := context.WithCancel(context.Background())
+//     defer cancel()
+//     go led.Keepalive(ctx, 5*time.Second)
+func (l *LED) Keepalive(ctx context.Context, timeout time.Duration) error {
+	if err := validateServerdownTimeout(timeout); err != nil {
+		return err
+	}
+
+	cfg := ServerdownConfig{Timeout: timeout}
+	if err := l.TickleContext(ctx, cfg); err != nil {
+		return fmt.Errorf("could not arm watchdog: %w", err)
+	}
+
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := l.TickleContext(ctx, cfg); err != nil && ctx.Err() == nil {
+				return fmt.Errorf("could not tickle watchdog: %w", err)
+			}
+		case <-ctx.Done():
+			// ctx is already done so the watchdog is disarmed without it
+			if err := l.DisarmServerdown(true); err != nil {
+				return fmt.Errorf("could not disarm watchdog: %w", err)
+			}
+			return nil
+		}
+	}
+}
+
+// validateServerdownTimeout checks that the timeout can be sent to the device.
+func validateServerdownTimeout(timeout time.Duration) error {
+	if timeout < MinServerdownTimeout || timeout > MaxServerdownTimeout {
+		return fmt.Errorf("serverdown timeout %s is out of range [%s, %s]", timeout, MinServerdownTimeout, MaxServerdownTimeout)
+	}
+
+	return nil
+}
+
+// validatePatternRange checks that start and end are valid pattern line positions of the device.
+func validatePatternRange(caps Capabilities, start, end int) error {
+	for _, pos := range []int{start, end} {
+		if err := validatePatternPosition(caps, pos); err != nil {
+			return err
+		}
+	}
+
+	if end < start {
+		return fmt.Errorf("pattern end %d is before start %d", end, start)
+	}
+
+	return nil
+}
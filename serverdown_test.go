@@ -0,0 +1,61 @@
+package blink
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeepalive(t *testing.T) {
+	tr := &syncTransport{}
+	led := NewLED(tr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, led.Keepalive(ctx, 20*time.Millisecond))
+
+	reports := tr.reports()
+	require.True(t, len(reports) >= 4, "expected the initial tickle, at least two more tickles and the disarm")
+
+	tickle := []byte{0x01, 'D', 0x01, 0x00, 0x02, 0x00, 0x00, 0x00}
+	for _, r := range reports[:len(reports)-1] {
+		assert.Equal(t, tickle, r)
+	}
+
+	disarm := []byte{0x01, 'D', 0x00, 0x00, 0x00, 0x01, 0x00, 0x00}
+	assert.Equal(t, disarm, reports[len(reports)-1])
+}
+
+func TestTickleValidatesPatternRange(t *testing.T) {
+	tr := &fakeTransport{responses: map[byte][]byte{'v': mk2Version}}
+	led := NewLED(tr)
+
+	assert.NoError(t, led.Tickle(ServerdownConfig{Timeout: time.Second, Start: 2, End: 31}))
+	assert.Error(t, led.Tickle(ServerdownConfig{Timeout: time.Second, Start: 2, End: 32}))
+	assert.Error(t, led.Tickle(ServerdownConfig{Timeout: time.Second, Start: 3, End: 2}))
+
+	tr = &fakeTransport{responses: map[byte][]byte{'v': {0x01, 'v', 0x00, '1', '5', 0x00, 0x00, 0x00}}}
+	err := NewLED(tr).Tickle(ServerdownConfig{Timeout: time.Second, Start: 2, End: 5})
+	assert.True(t, errors.Is(err, ErrUnsupported))
+}
+
+func TestTickleValidatesTimeout(t *testing.T) {
+	tr := &fakeTransport{}
+	led := NewLED(tr)
+
+	assert.Error(t, led.Tickle(ServerdownConfig{Timeout: 0}))
+	assert.Error(t, led.Tickle(ServerdownConfig{Timeout: 5 * time.Millisecond}))
+	assert.Error(t, led.Tickle(ServerdownConfig{Timeout: 11 * time.Minute}))
+	assert.Empty(t, tr.written)
+
+	require.NoError(t, led.Tickle(ServerdownConfig{Timeout: MaxServerdownTimeout}))
+	assert.Equal(t, []byte{0x01, 'D', 0x01, 0xff, 0xff, 0x00, 0x00, 0x00}, tr.written[0])
+
+	assert.Error(t, led.Keepalive(context.Background(), 0))
+	assert.Error(t, led.Keepalive(context.Background(), time.Nanosecond))
+}
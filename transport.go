@@ -0,0 +1,199 @@
+package blink
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// USBTimeOut is the maximum duration a call to the USB device can take before it will result in an error.
+// It is used for all calls whose context has no deadline.
+//
+// USBTimeOut applies to all devices and must not be changed while any LED is in use.
+// Use the context aware methods such as LED.SetContext to set a deadline for individual calls instead.
+var USBTimeOut = 1 * time.Second
+
+// A Transport sends and receives the HID feature reports which are used to
+// communicate with a blink(1) device. The first byte of each report is always
+// the report ID.
+//
+// The hidraw and libusb backends which are used by New are implementations
+// of this interface. Other implementations can be used via NewLED, for instance
+// to control a device over the network or to fake a device in unit tests.
+//
+// Implementations should abort a transfer and return an error when the given
+// context is done. If the context has no deadline USBTimeOut should be used.
+type Transport interface {
+	// WriteReport sends the given report to the device.
+	WriteReport(ctx context.Context, report []byte) error
+
+	// ReadReport reads a report from the device into the given buffer.
+	// The buffer must be sized to the expected report and its first byte
+	// must be set to the ID of the report that should be read.
+	ReadReport(ctx context.Context, report []byte) error
+
+	io.Closer
+}
+
+// conn is the connection to a blink(1) device.
+// All transfers are serialized so the connection can be used concurrently.
+type conn struct {
+	sem       chan struct{} // serializes all transfers
+	transport Transport
+	info      DeviceInfo
+	retry     RetryPolicy
+	scheduler *scheduler // nil unless WithRateLimit is used
+
+	mu             sync.Mutex    // guards caps and eepromUnlocked
+	caps           *Capabilities // nil until the capabilities have been read from the device
+	eepromUnlocked bool          // whether writes to the EEPROM are allowed
+}
+
+func newConn(t Transport) *conn {
+	return &conn{
+		sem:       make(chan struct{}, 1),
+		transport: t,
+	}
+}
+
+// An interrupter is a Transport whose transfers may block for a long time,
+// e.g. while waiting for a device to be reconnected.
+// Calling interrupt aborts all pending and future transfers.
+type interrupter interface {
+	interrupt()
+}
+
+// lock acquires exclusive access to the transport.
+// It returns an error if ctx is done before the access was granted.
+func (c *conn) lock(ctx context.Context) error {
+	select {
+	case c.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *conn) unlock() {
+	<-c.sem
+}
+
+// withDefaultTimeout returns a context with the deadline of ctx or with USBTimeOut if ctx has no deadline.
+func withDefaultTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, USBTimeOut)
+}
+
+// flush sends all commands which are queued by the write scheduler, so they
+// reach the device before any command which is sent afterwards.
+func (c *conn) flush(ctx context.Context) error {
+	if c.scheduler == nil {
+		return nil
+	}
+
+	return c.scheduler.flush(ctx)
+}
+
+// write sends the given command to the device after all queued commands.
+func (c *conn) write(ctx context.Context, cmd command) ([]byte, error) {
+	if err := c.flush(ctx); err != nil {
+		return nil, err
+	}
+
+	return c.send(ctx, cmd)
+}
+
+// send sends the given command to the device immediately.
+func (c *conn) send(ctx context.Context, cmd command) ([]byte, error) {
+	if err := c.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer c.unlock()
+
+	buf := cmd.bytes()
+	err := c.retry.do(ctx, func() error {
+		return c.transport.WriteReport(ctx, buf)
+	})
+
+	return buf, err
+}
+
+// writeAll sends the given commands to the device after all queued commands.
+// No other transfer can happen in between the commands.
+func (c *conn) writeAll(ctx context.Context, cmds ...command) error {
+	if err := c.flush(ctx); err != nil {
+		return err
+	}
+
+	return c.sendAll(ctx, cmds...)
+}
+
+// sendAll sends the given commands to the device immediately.
+// No other transfer can happen in between the commands.
+func (c *conn) sendAll(ctx context.Context, cmds ...command) error {
+	if err := c.lock(ctx); err != nil {
+		return err
+	}
+	defer c.unlock()
+
+	for _, cmd := range cmds {
+		buf := cmd.bytes()
+		err := c.retry.do(ctx, func() error {
+			return c.transport.WriteReport(ctx, buf)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// read sends the given command to the device after all queued commands and then reads back its response.
+// No other transfer can happen between sending the command and reading the response.
+func (c *conn) read(ctx context.Context, cmd command) ([]byte, error) {
+	if err := c.flush(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := c.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer c.unlock()
+
+	request := cmd.bytes()
+	buf := make([]byte, len(request))
+	err := c.retry.do(ctx, func() error {
+		copy(buf, request)
+		if err := c.transport.WriteReport(ctx, buf); err != nil {
+			return err
+		}
+
+		return c.transport.ReadReport(ctx, buf)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// close closes the transport once all pending transfers are done.
+func (c *conn) close() error {
+	if c.scheduler != nil {
+		c.scheduler.stop()
+	}
+
+	if t, ok := c.transport.(interrupter); ok {
+		t.interrupt()
+	}
+
+	c.sem <- struct{}{}
+	defer c.unlock()
+
+	return c.transport.Close()
+}
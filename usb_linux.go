@@ -1,4 +1,6 @@
-// +build linux
+//go:build linux && libusb
+// +build linux,libusb
+
 package blink
 
 // #cgo pkg-config: libusb-1.0
@@ -7,9 +9,9 @@ package blink
 import "C"
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"reflect"
 	"strings"
 	"time"
 	"unsafe"
@@ -22,59 +24,52 @@ const (
 	hidEndpointIn         = 0x80
 	hidGetReport          = 0x01
 	hidSetReport          = 0x09
+	hidReportTypeFeature  = 0x03
 )
 
-// USBTimeOut is the maximum duration a call to the USB device can take before it will result in an error.
-var USBTimeOut = 1 * time.Second
-
 func init() {
 	C.libusb_init(nil)
 }
 
-type usbDeviceInfo struct {
-	path                string
-	vendorID, productID uint16
-}
-
 type usbDevice struct {
 	handle *C.libusb_device_handle
-	info   usbDeviceInfo
+	info   DeviceInfo
 }
 
 // pretty much based on github.com/boombuler/hid
 // https://github.com/boombuler/hid/blob/08a7959390cac69dfd373882ac0a4435765a2545/hid_linux.go#L26
-func usbDevices() <-chan usbDeviceInfo {
-	result := make(chan usbDeviceInfo)
-	go func() {
-		var devices **C.struct_libusb_device
-		count := C.libusb_get_device_list(nil, &devices)
-		if count < 0 {
-			close(result)
-			return
+func usbDevices() ([]DeviceInfo, error) {
+	var devices **C.struct_libusb_device
+	count := C.libusb_get_device_list(nil, &devices)
+	if count < 0 {
+		return nil, usbError(count)
+	}
+	defer C.libusb_free_device_list(devices, 1)
+
+	var result []DeviceInfo
+	for _, dev := range slice(devices, count) {
+		di, err := readDeviceInfo(dev)
+		if err != nil {
+			continue
 		}
-		defer C.libusb_free_device_list(devices, 1)
 
-		for _, dev := range slice(devices, count) {
-			di, err := readDeviceInfo(dev)
-			if err != nil {
-				continue
-			}
-			result <- di
+		if di.isBlink1() {
+			di.Serial, di.Product = readStrings(dev)
 		}
 
-		close(result)
-	}()
+		result = append(result, di)
+	}
 
-	return result
+	return result, nil
 }
 
 // pretty much based on github.com/boombuler/hid
 // https://github.com/boombuler/hid/blob/08a7959390cac69dfd373882ac0a4435765a2545/hid_linux.go#L58
-func (di usbDeviceInfo) open() (*usbDevice, error) {
+func (di DeviceInfo) open() (*usbDevice, error) {
 	var devices **C.struct_libusb_device
 	cnt := C.libusb_get_device_list(nil, &devices)
 	if cnt < 0 {
-		return nil, fmt.Errorf("could not open usb device with path %q: could not list USB devices", di.path)
+		return nil, fmt.Errorf("could not open usb device with path %q: could not list USB devices", di.Path)
 	}
 	defer C.libusb_free_device_list(devices, 1)
 
@@ -84,13 +79,13 @@ func (di usbDeviceInfo) open() (*usbDevice, error) {
 			continue
 		}
 
-		if di.path == candidate.path {
-			dev := &usbDevice{info: candidate}
+		if di.Path == candidate.Path {
+			dev := &usbDevice{info: di}
 
 			var err error
 			result := C.libusb_open(d, &dev.handle)
 			if result != 0 {
-				err = usbError(result)
+				err = di.error(result)
 			}
 
 			return dev, err
@@ -100,7 +95,7 @@ func (di usbDeviceInfo) open() (*usbDevice, error) {
 	return nil, errors.New("couldn't open USB device")
 }
 
-func readDeviceInfo(dev *C.libusb_device) (di usbDeviceInfo, err error) {
+func readDeviceInfo(dev *C.libusb_device) (di DeviceInfo, err error) {
 	var desc C.struct_libusb_device_descriptor
 	if result := C.libusb_get_device_descriptor(dev, &desc); result < 0 {
 		return di, usbError(result)
@@ -111,13 +106,44 @@ func readDeviceInfo(dev *C.libusb_device) (di usbDeviceInfo, err error) {
 		return di, err
 	}
 
-	return usbDeviceInfo{
-		path:      fmt.Sprintf("%.4x:%.4x:%s", desc.idVendor, desc.idProduct, numbers),
-		vendorID:  uint16(desc.idVendor),
-		productID: uint16(desc.idProduct),
+	return DeviceInfo{
+		Path:      fmt.Sprintf("%.4x:%.4x:%s", desc.idVendor, desc.idProduct, numbers),
+		VendorID:  uint16(desc.idVendor),
+		ProductID: uint16(desc.idProduct),
 	}, nil
 }
 
+// readStrings reads the serial number and product string descriptors of the given device.
+// Empty strings are returned if the device could not be opened.
+func readStrings(dev *C.libusb_device) (serial, product string) {
+	var desc C.struct_libusb_device_descriptor
+	if result := C.libusb_get_device_descriptor(dev, &desc); result < 0 {
+		return "", ""
+	}
+
+	var handle *C.libusb_device_handle
+	if result := C.libusb_open(dev, &handle); result != 0 {
+		return "", ""
+	}
+	defer C.libusb_close(handle)
+
+	return stringDescriptor(handle, desc.iSerialNumber), stringDescriptor(handle, desc.iProduct)
+}
+
+func stringDescriptor(handle *C.libusb_device_handle, index C.uint8_t) string {
+	if index == 0 {
+		return ""
+	}
+
+	var buf [256]C.uchar
+	n := C.libusb_get_string_descriptor_ascii(handle, index, &buf[0], C.int(len(buf)))
+	if n < 0 {
+		return ""
+	}
+
+	return C.GoStringN((*C.char)(unsafe.Pointer(&buf[0])), n)
+}
+
 // entirely based on github.com/boombuler/hid
 // https://github.com/boombuler/hid/blob/08a7959390cac69dfd373882ac0a4435765a2545/hid_linux.go#L237
 func getPortNumbers(dev *C.libusb_device) (string, error) {
@@ -139,61 +165,80 @@ func getPortNumbers(dev *C.libusb_device) (string, error) {
 // entirely based on github.com/boombuler/hid
 // https://github.com/boombuler/hid/blob/08a7959390cac69dfd373882ac0a4435765a2545/hid_linux.go#L251
 func slice(devices **C.struct_libusb_device, cnt C.ssize_t) []*C.libusb_device {
-	var slice []*C.libusb_device
-	*(*reflect.SliceHeader)(unsafe.Pointer(&slice)) = reflect.SliceHeader{
-		Data: uintptr(unsafe.Pointer(devices)),
-		Len:  int(cnt),
-		Cap:  int(cnt),
-	}
-	return slice
+	return (*[1 << 28]*C.libusb_device)(unsafe.Pointer(devices))[:cnt:cnt]
 }
 
-func (d *usbDevice) write(c command) ([]byte, error) {
-	return d.readWrite(c,
+// WriteReport implements Transport by sending the report via a HID SET_REPORT control transfer.
+func (d *usbDevice) WriteReport(ctx context.Context, report []byte) error {
+	return d.readWrite(ctx, report,
 		hidEndpointOut|hidRecipientInterface|hidRequestTypeClass,
 		hidSetReport,
 	)
 }
 
-func (d *usbDevice) read(c command) ([]byte, error) {
-	return d.readWrite(c,
+// ReadReport implements Transport by filling report via a HID GET_REPORT control transfer.
+func (d *usbDevice) ReadReport(ctx context.Context, report []byte) error {
+	return d.readWrite(ctx, report,
 		hidEndpointIn|hidRecipientInterface|hidRequestTypeClass,
 		hidGetReport,
 	)
 }
 
-func (d *usbDevice) readWrite(c command, bmRequestType, bRequest int) ([]byte, error) {
+// readWrite performs a synchronous control transfer.
+// Because the transfer can not be cancelled once it has been started, the
+// deadline of ctx is used as timeout of the transfer.
+func (d *usbDevice) readWrite(ctx context.Context, data []byte, bmRequestType, bRequest int) error {
 	if d.handle == nil {
-		return nil, errors.New("usb device has not been opend")
+		return errors.New("usb device has not been opend")
 	}
 
-	data := c.bytes()
 	n := len(data)
+	if n == 0 {
+		return errors.New("can not transfer empty report")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return &DeviceError{Path: d.info.Path, Err: err}
+	}
+
+	timeout := USBTimeOut
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	if timeout < time.Millisecond {
+		timeout = time.Millisecond // a timeout of zero would block forever
+	}
 
 	written := C.libusb_control_transfer(d.handle,
 		C.uint8_t(bmRequestType),
 		C.uint8_t(bRequest),
-		C.uint16_t(reportID),
+		C.uint16_t(hidReportTypeFeature<<8|int(data[0])),
 		C.uint16_t(0),
 		(*C.uchar)(&data[0]),
 		C.uint16_t(n),
-		C.uint(USBTimeOut/time.Millisecond),
+		C.uint(timeout/time.Millisecond),
 	)
 
-	if int(written) == n {
-		return data, nil
+	switch {
+	case int(written) == n:
+		return nil
+	case written < 0:
+		return d.info.error(written)
+	default:
+		return &DeviceError{Path: d.info.Path, Err: fmt.Errorf("transferred only %d of %d bytes", written, n)}
 	}
-
-	return data, usbError(written)
 }
 
-func (d *usbDevice) close() {
+// Close implements Transport by closing the libusb device handle.
+// It is safe to call Close multiple times.
+func (d *usbDevice) Close() error {
 	if d == nil || d.handle == nil {
-		return
+		return nil
 	}
 
 	C.libusb_close(d.handle)
 	d.handle = nil
+	return nil
 }
 
 type usbError C.int
@@ -202,6 +247,29 @@ func (e usbError) Error() string {
 	return fmt.Sprintf("libusb: %s [code %d]", usbErrorString[e], int(e))
 }
 
+// Is maps the libusb error code to the error classes of this package.
+func (e usbError) Is(target error) bool {
+	switch e {
+	case C.LIBUSB_ERROR_ACCESS:
+		return target == ErrPermissionDenied
+	case C.LIBUSB_ERROR_NO_DEVICE:
+		return target == ErrDisconnected
+	case C.LIBUSB_ERROR_BUSY:
+		return target == ErrBusy
+	case C.LIBUSB_ERROR_TIMEOUT:
+		return target == ErrTimeout
+	case C.LIBUSB_ERROR_PIPE:
+		return target == ErrPipe
+	default:
+		return false
+	}
+}
+
+// error wraps the given libusb error code into a DeviceError.
+func (di DeviceInfo) error(code C.int) error {
+	return &DeviceError{Path: di.Path, Code: int(code), Err: usbError(code)}
+}
+
 var usbErrorString = map[usbError]string{
 	C.LIBUSB_SUCCESS:             "success",
 	C.LIBUSB_ERROR_IO:            "i/o error",
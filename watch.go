@@ -0,0 +1,128 @@
+package blink
+
+import (
+	"context"
+	"time"
+)
+
+// watchRetryInterval is the time after which Watch lists the devices again if
+// listing them failed or if it can not be notified about changes anymore.
+var watchRetryInterval = time.Second
+
+// EventType describes what happened to a device in an Event.
+type EventType int
+
+const (
+	// DeviceAdded signals that a blink(1) device has been connected.
+	DeviceAdded EventType = iota + 1
+
+	// DeviceRemoved signals that a blink(1) device has been disconnected.
+	DeviceRemoved
+)
+
+// String implements fmt.Stringer.
+func (t EventType) String() string {
+	switch t {
+	case DeviceAdded:
+		return "added"
+	case DeviceRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// An Event is emitted by Watch each time a blink(1) device is connected or disconnected.
+type Event struct {
+	Type   EventType
+	Device DeviceInfo
+}
+
+// Watch monitors the system for blink(1) devices that are connected or
+// disconnected and emits a corresponding Event on the returned channel.
+// Initially Watch emits a DeviceAdded event for each device that is already
+// connected. The returned channel is closed when ctx is cancelled.
+// If the devices can not be listed, Watch retries until it succeeds.
+//
+// The caller must keep receiving events until the channel is closed.
+// Note that a device might not be accessible for a short time after it has been
+// added because udev might still be busy applying its permissions.
+func Watch(ctx context.Context) (<-chan Event, error) {
+	changes, err := listenDeviceChanges(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return watch(ctx, changes, List), nil
+}
+
+// watch lists the devices via list each time a value is received from changes
+// and emits the differences to the previously listed devices.
+// If changes is closed before ctx is done, the devices are polled instead.
+func watch(ctx context.Context, changes <-chan struct{}, list func() ([]DeviceInfo, error)) <-chan Event {
+	var known []DeviceInfo
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+
+		for {
+			var retry <-chan time.Time
+			devices, err := list()
+			if err == nil {
+				for _, e := range diffDevices(known, devices) {
+					select {
+					case events <- e:
+					case <-ctx.Done():
+						return
+					}
+				}
+				known = devices
+			}
+
+			if err != nil || changes == nil {
+				retry = time.After(watchRetryInterval)
+			}
+
+			select {
+			case _, ok := <-changes:
+				if !ok {
+					changes = nil // fall back to polling
+				}
+			case <-retry:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+// diffDevices returns the events that lead from the before to the after set of devices.
+// Devices are identified by their path.
+func diffDevices(before, after []DeviceInfo) []Event {
+	var events []Event
+	for _, di := range before {
+		if !containsPath(after, di.Path) {
+			events = append(events, Event{Type: DeviceRemoved, Device: di})
+		}
+	}
+
+	for _, di := range after {
+		if !containsPath(before, di.Path) {
+			events = append(events, Event{Type: DeviceAdded, Device: di})
+		}
+	}
+
+	return events
+}
+
+func containsPath(devices []DeviceInfo, path string) bool {
+	for _, di := range devices {
+		if di.Path == path {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,100 @@
+package blink
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// listenDeviceChanges listens for kernel uevents via netlink and sends a value
+// on the returned channel whenever a USB or hidraw device has been added or removed.
+// Multiple changes that happen before the value was received are coalesced.
+// If uevents were lost because the socket buffer overflowed, a change is
+// signalled as well. The channel is closed when ctx is cancelled.
+func listenDeviceChanges(ctx context.Context) (<-chan struct{}, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK,
+		syscall.SOCK_RAW|syscall.SOCK_CLOEXEC|syscall.SOCK_NONBLOCK,
+		syscall.NETLINK_KOBJECT_UEVENT,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not create netlink socket: %s", err)
+	}
+
+	err = syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: 1})
+	if err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("could not bind netlink socket: %s", err)
+	}
+
+	// the socket is non-blocking so closing f will unblock any pending Read
+	f := os.NewFile(uintptr(fd), "netlink-uevent")
+	go func() {
+		<-ctx.Done()
+		f.Close()
+	}()
+
+	changes := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+
+	go func() {
+		defer close(changes)
+
+		buf := make([]byte, 8192)
+		for {
+			n, err := f.Read(buf)
+			switch {
+			case errors.Is(err, syscall.ENOBUFS):
+				notify() // some uevents were dropped so any device might have changed
+				continue
+			case errors.Is(err, syscall.EINTR):
+				continue
+			case err != nil:
+				return // f has been closed or can not be read anymore, Watch falls back to polling
+			}
+
+			if isDeviceChange(parseUevent(buf[:n])) {
+				notify()
+			}
+		}
+	}()
+
+	return changes, nil
+}
+
+// parseUevent parses the NUL separated KEY=VALUE pairs of a kernel uevent message.
+// The first line of the message (e.g. "add@/devices/...") is ignored.
+func parseUevent(msg []byte) map[string]string {
+	env := map[string]string{}
+	for _, field := range bytes.Split(msg, []byte{0}) {
+		i := bytes.IndexByte(field, '=')
+		if i < 0 {
+			continue
+		}
+		env[string(field[:i])] = string(field[i+1:])
+	}
+
+	return env
+}
+
+func isDeviceChange(env map[string]string) bool {
+	switch env["ACTION"] {
+	case "add", "remove":
+	default:
+		return false
+	}
+
+	switch env["SUBSYSTEM"] {
+	case "usb", "hidraw":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,84 @@
+package blink
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffDevices(t *testing.T) {
+	a := DeviceInfo{Path: "27b8:01ed:01"}
+	b := DeviceInfo{Path: "27b8:01ed:02"}
+	c := DeviceInfo{Path: "27b8:01ed:03"}
+
+	assert.Empty(t, diffDevices(nil, nil))
+	assert.Equal(t, []Event{{DeviceAdded, a}, {DeviceAdded, b}}, diffDevices(nil, []DeviceInfo{a, b}))
+	assert.Equal(t, []Event{{DeviceRemoved, a}, {DeviceAdded, c}}, diffDevices([]DeviceInfo{a, b}, []DeviceInfo{b, c}))
+	assert.Equal(t, []Event{{DeviceRemoved, b}}, diffDevices([]DeviceInfo{a, b}, []DeviceInfo{a}))
+}
+
+func TestParseUevent(t *testing.T) {
+	msg := []byte("add@/devices/usb1/1-2\x00ACTION=add\x00DEVPATH=/devices/usb1/1-2\x00SUBSYSTEM=usb\x00")
+	env := parseUevent(msg)
+	assert.Equal(t, map[string]string{
+		"ACTION":    "add",
+		"DEVPATH":   "/devices/usb1/1-2",
+		"SUBSYSTEM": "usb",
+	}, env)
+	assert.True(t, isDeviceChange(env))
+
+	env["ACTION"] = "bind"
+	assert.False(t, isDeviceChange(env))
+
+	env["ACTION"] = "remove"
+	env["SUBSYSTEM"] = "input"
+	assert.False(t, isDeviceChange(env))
+}
+
+func TestWatchLoop(t *testing.T) {
+	defer func(d time.Duration) { watchRetryInterval = d }(watchRetryInterval)
+	watchRetryInterval = time.Millisecond
+
+	a := DeviceInfo{Path: "27b8:01ed:01"}
+	b := DeviceInfo{Path: "27b8:01ed:02"}
+
+	var mu sync.Mutex
+	devices, listErr := []DeviceInfo{a}, error(nil)
+	list := func() ([]DeviceInfo, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return devices, listErr
+	}
+	update := func(d []DeviceInfo, err error) {
+		mu.Lock()
+		devices, listErr = d, err
+		mu.Unlock()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan struct{}, 1)
+	events := watch(ctx, changes, list)
+	assert.Equal(t, Event{DeviceAdded, a}, <-events)
+
+	// listing errors are retried
+	update(nil, errors.New("busy"))
+	changes <- struct{}{}
+	time.Sleep(5 * time.Millisecond)
+	update([]DeviceInfo{a, b}, nil)
+	assert.Equal(t, Event{DeviceAdded, b}, <-events)
+
+	// the devices are polled once the changes can not be received anymore
+	close(changes)
+	update([]DeviceInfo{b}, nil)
+	assert.Equal(t, Event{DeviceRemoved, a}, <-events)
+
+	cancel()
+	for range events {
+	}
+}